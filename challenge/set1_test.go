@@ -13,7 +13,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/cipher/block"
-	"github.com/t-bast/cryptopals/score"
 	"github.com/t-bast/cryptopals/xor"
 )
 
@@ -42,25 +41,12 @@ func TestSet1_Challenge4(t *testing.T) {
 	require.NoError(t, err)
 	defer f.Close()
 
-	var bestCandidate string
-	var bestScore float32
-
-	reader := bufio.NewReader(f)
-	for {
-		line, _, err := reader.ReadLine()
-		if err == io.EOF {
-			break
-		}
-
-		candidate, _ := xor.DecryptSingle(string(line))
-		score := score.LetterFrequency([]byte(candidate))
-		if score > bestScore {
-			bestScore = score
-			bestCandidate = candidate
-		}
-	}
+	detections, err := xor.DetectSingleByte(f, xor.HexLines)
+	require.NoError(t, err)
+	require.NotEmpty(t, detections)
 
-	assert.Equal(t, "Now that the party is jumping\n", bestCandidate)
+	assert.Equal(t, 171, detections[0].Line)
+	assert.Equal(t, "Now that the party is jumping\n", string(detections[0].Plaintext))
 }
 
 func TestSet1_Challenge5(t *testing.T) {
@@ -88,7 +74,8 @@ func TestSet1_Challenge7(t *testing.T) {
 	encryptedBytes, err := base64.StdEncoding.DecodeString(string(encrypted))
 	require.NoError(t, err)
 
-	decrypted := block.NewECB([]byte("YELLOW SUBMARINE")).Decrypt(encryptedBytes)
+	decrypted, err := block.NewECB([]byte("YELLOW SUBMARINE")).Decrypt(encryptedBytes)
+	require.NoError(t, err)
 	assert.Equal(t, "I'm back and I'm ringin' the bell \nA rockin' on the mike while the fly girls yell \nIn ecstasy in the back of me \nWell that's my DJ Deshay cuttin' all them Z's \nHittin' hard and the girlies goin' crazy \nVanilla's on the mike, man I'm not lazy. \n\nI'm lettin' my drug kick in \nIt controls my mouth and I begin \nTo just let it flow, let my concepts go \nMy posse's to the side yellin', Go Vanilla Go! \n\nSmooth 'cause that's the way I will be \nAnd if you don't give a damn, then \nWhy you starin' at me \nSo get off 'cause I control the stage \nThere's no dissin' allowed \nI'm in my own phase \nThe girlies sa y they love me and that is ok \nAnd I can dance better than any kid n' play \n\nStage 2 -- Yea the one ya' wanna listen to \nIt's off my head so let the beat play through \nSo I can funk it up and make it sound good \n1-2-3 Yo -- Knock on some wood \nFor good luck, I like my rhymes atrocious \nSupercalafragilisticexpialidocious \nI'm an effect and that you can bet \nI can take a fly girl and make her wet. \n\nI'm like Samson -- Samson to Delilah \nThere's no denyin', You can try to hang \nBut you'll keep tryin' to get my style \nOver and over, practice makes perfect \nBut not if you're a loafer. \n\nYou'll get nowhere, no place, no time, no girls \nSoon -- Oh my God, homebody, you probably eat \nSpaghetti with a spoon! Come on and say it! \n\nVIP. Vanilla Ice yep, yep, I'm comin' hard like a rhino \nIntoxicating so you stagger like a wino \nSo punks stop trying and girl stop cryin' \nVanilla Ice is sellin' and you people are buyin' \n'Cause why the freaks are jockin' like Crazy Glue \nMovin' and groovin' trying to sing along \nAll through the ghetto groovin' this here song \nNow you're amazed by the VIP posse. \n\nSteppin' so hard like a German Nazi \nStartled by the bases hittin' ground \nThere's no trippin' on mine, I'm just gettin' down \nSparkamatic, I'm hangin' tight like a fanatic \nYou trapped me once and I thought that \nYou might have it \nSo step down and lend me your ear \n'89 in my time! You, '90 is my year. \n\nYou're weakenin' fast, YO! and I can tell it \nYour body's gettin' hot, so, so I can smell it \nSo don't be mad and don't be sad \n'Cause the lyrics belong to ICE, You can call me Dad \nYou're pitchin' a fit, so step back and endure \nLet the witch doctor, Ice, do the dance to cure \nSo come up close and don't be square \nYou wanna battle me -- Anytime, anywhere \n\nYou thought that I was weak, Boy, you're dead wrong \nSo come on, everybody and sing this song \n\nSay -- Play that funky music Say, go white boy, go white boy go \nplay that funky music Go white boy, go white boy, go \nLay down and boogie and play that funky music till you die. \n\nPlay that funky music Come on, Come on, let me hear \nPlay that funky music white boy you say it, say it \nPlay that funky music A little louder now \nPlay that funky music, white boy Come on, Come on, Come on \nPlay that funky music \n", string(decrypted))
 }
 
@@ -98,9 +85,7 @@ func TestSet1_Challenge8(t *testing.T) {
 	require.NoError(t, err)
 	defer f.Close()
 
-	bestScore := 0
-	bestCandidate := make([]byte, 160)
-
+	var ciphertexts [][]byte
 	reader := bufio.NewReader(f)
 	for {
 		line, _, err := reader.ReadLine()
@@ -110,26 +95,11 @@ func TestSet1_Challenge8(t *testing.T) {
 
 		lineBytes, err := hex.DecodeString(string(line))
 		require.NoError(t, err)
-
-		score := 0
-
-		// Check if 16-byte blocks repeat themselves.
-		blocks := make(map[string]struct{})
-		for i := 0; i < len(lineBytes)/16; i++ {
-			key := string(lineBytes[i*16 : (i+1)*16])
-			_, ok := blocks[key]
-			if ok {
-				score++
-			} else {
-				blocks[key] = struct{}{}
-			}
-		}
-
-		if score > bestScore {
-			bestScore = score
-			copy(bestCandidate, lineBytes)
-		}
+		ciphertexts = append(ciphertexts, lineBytes)
 	}
 
-	assert.Equal(t, 3, bestScore)
+	ranked := block.RankECB(ciphertexts, 16)
+	assert.Equal(t, 132, ranked[0].Index)
+	assert.Equal(t, 3, ranked[0].Repeats)
+	assert.Equal(t, 0, ranked[1].Repeats)
 }
@@ -9,7 +9,6 @@ import (
 	mrand "math/rand"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,11 +17,16 @@ import (
 	"github.com/t-bast/cryptopals/distance"
 	"github.com/t-bast/cryptopals/oracle"
 	"github.com/t-bast/cryptopals/prng"
+	"github.com/t-bast/cryptopals/score"
 	"github.com/t-bast/cryptopals/xor"
 )
 
 func TestSet3_Challenge1(t *testing.T) {
-	assert.NoError(t, oracle.DecryptWithPaddingOracle(oracle.NewPaddingOracle()))
+	o, encrypted := oracle.NewPaddingOracle()
+	decrypted, queries, err := oracle.DecryptWithPaddingOracle(o.CheckPadding, encrypted, oracle.PaddingAttackOptions{IV: o.IV})
+	require.NoError(t, err)
+	assert.Equal(t, string(o.Secret), string(decrypted))
+	assert.Less(t, queries, 256*len(encrypted))
 }
 
 func TestSet3_Challenge2(t *testing.T) {
@@ -62,14 +66,15 @@ func TestSet3_Challenge3(t *testing.T) {
 		encrypted = append(encrypted, ctr.Encrypt(lineBytes))
 	}
 
-	keystream := stream.PwnCTRNonceReuseLetterFrequency(encrypted)
+	keystream, confidence := stream.BreakNonceReuse(encrypted, score.English())
 	decrypted := xor.Bytes(encrypted[0], keystream[:len(encrypted[0])])
+	assert.Equal(t, "I have met them at close of day", string(decrypted))
 
-	// The current method does a single pass on letter frequency.
-	// It works well for the beginning of the message but can miss the end.
-	// However it's then easy to test several candidate keystream bytes for the
-	// end of the message manually until it makes sense.
-	assert.Equal(t, 0, strings.Index(string(decrypted), "I have met them at close of"))
+	// The first line is covered by enough ciphertexts to be confident: only
+	// the end of the longest lines can't be recovered reliably.
+	for i := range confidence[:len(encrypted[0])] {
+		assert.True(t, confidence[i] > 0.5)
+	}
 }
 
 func TestSet3_Challenge4(t *testing.T) {
@@ -104,7 +109,7 @@ func TestSet3_Challenge4(t *testing.T) {
 		}
 	}
 
-	keystream := stream.PwnCTRNonceReuseStatistical(encrypted)
+	keystream := stream.PwnCTRNonceReuseStatistical(encrypted, score.LetterFrequencyScorer)
 	smallestDecrypted := xor.Bytes(smallestEncrypted, keystream[:len(smallestEncrypted)])
 
 	// We're not finding exactly the right result (one letter is off).
@@ -15,7 +15,6 @@ import (
 	"github.com/t-bast/cryptopals/hash"
 	"github.com/t-bast/cryptopals/mac"
 	"github.com/t-bast/cryptopals/oracle"
-	"github.com/t-bast/cryptopals/xor"
 )
 
 func TestSet4_Challenge1(t *testing.T) {
@@ -26,7 +25,8 @@ func TestSet4_Challenge1(t *testing.T) {
 	encryptedBytes, err := base64.StdEncoding.DecodeString(string(encrypted))
 	require.NoError(t, err)
 
-	decrypted := block.NewECB([]byte("YELLOW SUBMARINE")).Decrypt(encryptedBytes)
+	decrypted, err := block.NewECB([]byte("YELLOW SUBMARINE")).Decrypt(encryptedBytes)
+	require.NoError(t, err)
 
 	sk := "YELLOW SUBMARINE"
 	ctr := stream.NewCTR([]byte(sk), 0)
@@ -49,38 +49,24 @@ func TestSet4_Challenge1(t *testing.T) {
 
 func TestSet4_Challenge2(t *testing.T) {
 	o := oracle.NewCTROracle()
+	encrypt := func(plaintext []byte) []byte { return o.Encrypt(string(plaintext)) }
 
-	// Instead of ; and = that are going to be replaced by the encrypt method,
-	// we use the ascii char just below them.
-	encrypted := o.Encrypt("\x3aadmin\x3ctrue\x3a")
-
-	// Then we do some bit-flipping on the ciphertext to change our inserted
-	// characters to ; and =.
-	encrypted[32] ^= 1
-	encrypted[38] ^= 1
-	encrypted[43] ^= 1
-
-	assert.True(t, o.CheckAdmin(encrypted))
+	// The oracle escapes ; and = so we encrypt a placeholder instead, and flip
+	// its bits in place.
+	f := oracle.Flipper{Mode: oracle.FlipCTR}
+	_, err := f.Attack(encrypt, o.CheckAdmin, len("comment1=cooking%20MCs;userdata="), []byte(";admin=true;"))
+	assert.NoError(t, err)
 }
 
 func TestSet4_Challenge3(t *testing.T) {
-	key := []byte("YELLOW SUBMARINE")
-	cbc := block.NewCBC(key, key)
-
-	ciphertext := cbc.Encrypt([]byte("Je laisse a Gavarni, poete des chloroses, Son tr"))
-	var modified []byte
-	modified = append(modified, ciphertext[:16]...)
-	modified = append(modified, make([]byte, 16)...)
-	modified = append(modified, ciphertext[:16]...)
-
-	defer func() {
-		r := recover().(map[string]string)
-		recoveredKey := xor.Bytes([]byte(r["msg"][:16]), []byte(r["msg"][32:48]))
-		assert.Equal(t, key, recoveredKey)
-	}()
-
-	cbc.Decrypt(modified)
-	assert.Fail(t, "should have panicked")
+	o := oracle.NewIVKeyOracle()
+	ciphertext := o.Encrypt("Je laisse a Gavarni, poete des chloroses, Son tr")
+
+	// The oracle rejects the high-ASCII plaintext of C1 || 0 || C1 and leaks
+	// it in the error.
+	key, err := oracle.RecoverKeyFromIVReuse(o.Check, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, o.Key, key)
 }
 
 func TestSet4_Challenge4(t *testing.T) {
@@ -64,12 +64,14 @@ func TestSet5_Challenge2(t *testing.T) {
 	rand.Read(aliceIV)
 
 	aliceCBC := block.NewCBC(aliceSharedKey[0:16], aliceIV)
-	aliceEncrypted := aliceCBC.Encrypt([]byte("Ma jeunesse ne fut qu'un ténébreux orage"))
+	aliceEncrypted, err := aliceCBC.Encrypt([]byte("Ma jeunesse ne fut qu'un ténébreux orage"))
+	require.NoError(t, err)
 
 	// M -> B: simply relay while reading decrypted plaintext.
 	mallorySharedKey := sha1.Sum(big.NewInt(0).Bytes())
 	malloryCBC := block.NewCBC(mallorySharedKey[0:16], aliceIV)
-	intercepted := malloryCBC.Decrypt(aliceEncrypted)
+	intercepted, err := malloryCBC.Decrypt(aliceEncrypted)
+	require.NoError(t, err)
 	assert.Equal(t, []byte("Ma jeunesse ne fut qu'un ténébreux orage"), intercepted)
 
 	// B -> M: AES-CBC(SHA1(s)[0:16], IV=random(16), alice's message) + IV
@@ -78,19 +80,23 @@ func TestSet5_Challenge2(t *testing.T) {
 	rand.Read(bobIV)
 
 	// Bob is able to read Alice's message so doesn't suspect anything.
-	bobDecrypted := block.NewCBC(bobSharedKey[0:16], aliceIV).Decrypt(aliceEncrypted)
+	bobDecrypted, err := block.NewCBC(bobSharedKey[0:16], aliceIV).Decrypt(aliceEncrypted)
+	require.NoError(t, err)
 	assert.Equal(t, []byte("Ma jeunesse ne fut qu'un ténébreux orage"), bobDecrypted)
 
 	bobCBC := block.NewCBC(bobSharedKey[0:16], bobIV)
-	bobEncrypted := bobCBC.Encrypt([]byte("Traversé çà et là par de brillants soleils;"))
+	bobEncrypted, err := bobCBC.Encrypt([]byte("Traversé çà et là par de brillants soleils;"))
+	require.NoError(t, err)
 
 	// M -> A: simply relay while reading decrypted plaintext.
 	malloryCBC = block.NewCBC(mallorySharedKey[0:16], bobIV)
-	intercepted = malloryCBC.Decrypt(bobEncrypted)
+	intercepted, err = malloryCBC.Decrypt(bobEncrypted)
+	require.NoError(t, err)
 	assert.Equal(t, []byte("Traversé çà et là par de brillants soleils;"), intercepted)
 
 	// Alice is able to read Bob's message so doesn't suspect anything.
-	aliceDecrypted := block.NewCBC(aliceSharedKey[0:16], bobIV).Decrypt(bobEncrypted)
+	aliceDecrypted, err := block.NewCBC(aliceSharedKey[0:16], bobIV).Decrypt(bobEncrypted)
+	require.NoError(t, err)
 	assert.Equal(t, []byte("Traversé çà et là par de brillants soleils;"), aliceDecrypted)
 }
 
@@ -109,7 +115,9 @@ func TestSet5_Challenge3(t *testing.T) {
 			// always ends up being 1 too.
 			sharedKey := sha1.Sum(big.NewInt(1).Bytes())
 			cbc := block.NewCBC(sharedKey[0:16], iv)
-			return cbc.Decrypt(encrypted)
+			decrypted, err := cbc.Decrypt(encrypted)
+			require.NoError(t, err)
+			return decrypted
 		},
 	}, {
 		modifiedG: p,
@@ -118,30 +126,30 @@ func TestSet5_Challenge3(t *testing.T) {
 			// always ends up being 0 too.
 			sharedKey := sha1.Sum(big.NewInt(0).Bytes())
 			cbc := block.NewCBC(sharedKey[0:16], iv)
-			return cbc.Decrypt(encrypted)
+			decrypted, err := cbc.Decrypt(encrypted)
+			require.NoError(t, err)
+			return decrypted
 		},
 	}, {
 		modifiedG: new(big.Int).Sub(p, big.NewInt(1)),
-		decryptMallory: func(t *testing.T, encrypted []byte, iv []byte) (res []byte) {
+		decryptMallory: func(t *testing.T, encrypted []byte, iv []byte) []byte {
 			// Bob's public key will be (-1)^sk(alice).
 			// Alice's shared key will be (-1)^(sk(alice)*sk(alice)).
 			// If Alice's private key is 1, this will be (p-1).
 			// Otherwise (in most cases) it will be 1.
 			sharedKey := sha1.Sum(big.NewInt(1).Bytes())
 			cbc := block.NewCBC(sharedKey[0:16], iv)
-
-			defer func() {
-				if r := recover(); r != nil {
-					// If we're here that means alice's private key is 1.
-					// And thus its shared key will be (p-1).
-					sharedKey := sha1.Sum(new(big.Int).Sub(p, big.NewInt(1)).Bytes())
-					cbc := block.NewCBC(sharedKey[0:16], iv)
-					res = cbc.Decrypt(encrypted)
-				}
-			}()
-
-			res = cbc.Decrypt(encrypted)
-			return
+			decrypted, err := cbc.Decrypt(encrypted)
+			if err != nil {
+				// If we're here that means alice's private key is 1.
+				// And thus its shared key will be (p-1).
+				sharedKey := sha1.Sum(new(big.Int).Sub(p, big.NewInt(1)).Bytes())
+				cbc := block.NewCBC(sharedKey[0:16], iv)
+				decrypted, err = cbc.Decrypt(encrypted)
+				require.NoError(t, err)
+			}
+
+			return decrypted
 		},
 	}}
 
@@ -168,7 +176,8 @@ func TestSet5_Challenge3(t *testing.T) {
 			aliceIV := make([]byte, 16)
 			rand.Read(aliceIV)
 			aliceCBC := block.NewCBC(aliceSharedSecret[0:16], aliceIV)
-			aliceEncrypted := aliceCBC.Encrypt([]byte("Une fleur qui ressemble à mon rouge idéal."))
+			aliceEncrypted, err := aliceCBC.Encrypt([]byte("Une fleur qui ressemble à mon rouge idéal."))
+			require.NoError(t, err)
 
 			// Mallory can intercept and decrypt.
 			malloryDecrypted := tt.decryptMallory(t, aliceEncrypted, aliceIV)
@@ -178,7 +187,8 @@ func TestSet5_Challenge3(t *testing.T) {
 			bobIV := make([]byte, 16)
 			rand.Read(bobIV)
 			bobCBC := block.NewCBC(bobSharedSecret[0:16], bobIV)
-			bobEncrypted := bobCBC.Encrypt([]byte("Car je ne puis trouver parmi ces pâles roses"))
+			bobEncrypted, err := bobCBC.Encrypt([]byte("Car je ne puis trouver parmi ces pâles roses"))
+			require.NoError(t, err)
 
 			malloryDecrypted = tt.decryptMallory(t, bobEncrypted, bobIV)
 			assert.Equal(t, []byte("Car je ne puis trouver parmi ces pâles roses"), malloryDecrypted)
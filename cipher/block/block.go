@@ -0,0 +1,70 @@
+package block
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// ErrInvalidLength is returned when decrypting a ciphertext that isn't
+	// made of full blocks.
+	ErrInvalidLength = errors.New("ciphertext is not a multiple of the block size")
+	// ErrInvalidIV is returned when the IV isn't exactly one block long.
+	ErrInvalidIV = errors.New("IV length must equal block size")
+	// ErrTooShort is returned when a mode needs at least one full block of
+	// input.
+	ErrTooShort = errors.New("input is shorter than one block")
+)
+
+// Cipher encrypts and decrypts whole messages with a block cipher mode.
+// ECB, CBC, PCBC, CTS, CFB and OFB all implement it.
+type Cipher interface {
+	Encrypt(message []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// schedule caches the AES key schedule, so that modes don't expand their key
+// again for every message.
+// It is safe for concurrent use.
+type schedule struct {
+	cached atomic.Value
+}
+
+type scheduledKey struct {
+	key []byte
+	b   cipher.Block
+}
+
+// block returns the AES block cipher for the key.
+// The key is only expanded again when it changed since the last call.
+func (s *schedule) block(key []byte) (cipher.Block, error) {
+	if c, ok := s.cached.Load().(*scheduledKey); ok && bytes.Equal(c.key, key) {
+		return c.b, nil
+	}
+
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cached.Store(&scheduledKey{key: append([]byte(nil), key...), b: b})
+	return b, nil
+}
+
+// newBlock returns the AES block cipher for the key and checks that the IV is
+// one block long.
+func (s *schedule) newBlock(key, iv []byte) (cipher.Block, error) {
+	b, err := s.block(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != b.BlockSize() {
+		return nil, ErrInvalidIV
+	}
+
+	return b, nil
+}
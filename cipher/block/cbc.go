@@ -1,16 +1,20 @@
 package block
 
 import (
-	"crypto/aes"
+	"crypto/cipher"
 
 	"github.com/t-bast/cryptopals/cipher/padding"
-	"github.com/t-bast/cryptopals/xor"
 )
 
 // CBC implements CBC encryption with AES.
+// The key size selects AES-128, AES-192 or AES-256.
 type CBC struct {
 	Key []byte
 	IV  []byte
+	// Padding scheme used by Encrypt and Decrypt, PKCS#7 if nil.
+	Padding padding.Scheme
+
+	schedule schedule
 }
 
 // NewCBC creates a new CBC encryptor with the given key and IV.
@@ -18,51 +22,53 @@ func NewCBC(key []byte, iv []byte) *CBC {
 	return &CBC{Key: key, IV: iv}
 }
 
-// Encrypt the given message.
-func (c *CBC) Encrypt(message []byte) []byte {
-	b, err := aes.NewCipher(c.Key)
+// Encrypter returns a cipher.BlockMode that encrypts with the key and IV,
+// without padding.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CBC) Encrypter() (cipher.BlockMode, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	blockLen := len(c.Key)
-	v := make([]byte, len(c.IV))
-	copy(v, c.IV)
+	return NewCBCEncrypter(b, c.IV), nil
+}
 
-	paddedMsg := padding.PKCS7(message, blockLen)
-	encrypted := make([]byte, len(paddedMsg))
-	for i := 0; i < len(paddedMsg)/blockLen; i++ {
-		start := i * blockLen
-		end := start + blockLen
-		b.Encrypt(encrypted[start:end], xor.Bytes(paddedMsg[start:end], v))
-		copy(v, encrypted[start:end])
+// Decrypter returns a cipher.BlockMode that decrypts with the key and IV,
+// without removing padding.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CBC) Decrypter() (cipher.BlockMode, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
 	}
 
-	return encrypted
+	return NewCBCDecrypter(b, c.IV), nil
 }
 
-// Decrypt the given ciphertext.
-func (c *CBC) Decrypt(ciphertext []byte) []byte {
-	b, err := aes.NewCipher(c.Key)
+// Encrypt the given message.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CBC) Encrypt(message []byte) ([]byte, error) {
+	m, err := c.Encrypter()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	blockLen := len(c.Key)
-	v := make([]byte, len(c.IV))
-	copy(v, c.IV)
+	return encryptBlocks(m, message, c.Padding), nil
+}
 
-	decrypted := make([]byte, len(ciphertext))
-	for i := 0; i < len(ciphertext)/blockLen; i++ {
-		start := i * blockLen
-		end := start + blockLen
-		block := make([]byte, blockLen)
-		b.Decrypt(block, ciphertext[start:end])
-		copy(decrypted[start:end], xor.Bytes(block, v))
-		copy(v, ciphertext[start:end])
+// Decrypt the given ciphertext.
+// It returns an error if the key isn't a valid AES key, if the IV isn't one
+// block long, if the ciphertext isn't made of full blocks or if its padding is
+// invalid.
+func (c *CBC) Decrypt(ciphertext []byte) ([]byte, error) {
+	m, err := c.Decrypter()
+	if err != nil {
+		return nil, err
 	}
 
-	msg := padding.UnPKCS7(decrypted, blockLen)
-
-	return msg
+	return decryptBlocks(m, ciphertext, c.Padding)
 }
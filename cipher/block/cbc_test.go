@@ -1,20 +1,113 @@
 package block_test
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
 func TestCBC(t *testing.T) {
-	key := "YELLOW SUBMARINE"
 	iv := [16]byte{0}
-	cbc := block.NewCBC([]byte(key), iv[:])
-
 	message := "Yellow, yellow submarine yellow!"
-	encrypted := cbc.Encrypt([]byte(message))
-	decrypted := cbc.Decrypt(encrypted)
 
-	assert.Equal(t, message, string(decrypted))
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			cbc := block.NewCBC(key, iv[:])
+			encrypted, err := cbc.Encrypt([]byte(message))
+			require.NoError(t, err)
+
+			// Compare with the standard library.
+			b, err := aes.NewCipher(key)
+			require.NoError(t, err)
+			expected := padding.PKCS7([]byte(message), aes.BlockSize)
+			cipher.NewCBCEncrypter(b, iv[:]).CryptBlocks(expected, expected)
+			assert.Equal(t, expected, encrypted)
+
+			decrypted, err := cbc.Decrypt(encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, message, string(decrypted))
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := block.NewCBC([]byte("YELLOW"), iv[:]).Encrypt([]byte(message))
+		assert.Equal(t, aes.KeySizeError(6), err)
+	})
+
+	t.Run("invalid IV", func(t *testing.T) {
+		_, err := block.NewCBC(keys["AES-256"], make([]byte, 32)).Encrypt([]byte(message))
+		assert.Equal(t, block.ErrInvalidIV, err)
+	})
+
+	t.Run("invalid ciphertext", func(t *testing.T) {
+		cbc := block.NewCBC(keys["AES-128"], iv[:])
+		_, err := cbc.Decrypt(make([]byte, 17))
+		assert.Equal(t, block.ErrInvalidLength, err)
+
+		encrypted, err := cbc.Encrypt([]byte(message))
+		require.NoError(t, err)
+		encrypted[len(encrypted)-17] ^= 0x42
+		_, err = cbc.Decrypt(encrypted)
+		assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+	})
+
+	t.Run("padding scheme", func(t *testing.T) {
+		cbc := block.NewCBC(keys["AES-128"], iv[:])
+		cbc.Padding = padding.SchemeISO7816
+		encrypted, err := cbc.Encrypt([]byte(message))
+		require.NoError(t, err)
+
+		decrypted, err := cbc.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, message, string(decrypted))
+
+		_, err = block.NewCBC(keys["AES-128"], iv[:]).Decrypt(encrypted)
+		assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+
+		// Zero padding still encrypts empty messages to a block.
+		cbc.Padding = padding.SchemeZero
+		encrypted, err = cbc.Encrypt(nil)
+		require.NoError(t, err)
+		assert.Len(t, encrypted, 16)
+
+		decrypted, err = cbc.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Empty(t, decrypted)
+	})
+
+	t.Run("key change", func(t *testing.T) {
+		cbc := block.NewCBC(keys["AES-128"], iv[:])
+		_, err := cbc.Encrypt([]byte(message))
+		require.NoError(t, err)
+
+		// The cached key schedule follows the key.
+		cbc.Key = keys["AES-256"]
+		encrypted, err := cbc.Encrypt([]byte(message))
+		require.NoError(t, err)
+		expected, err := block.NewCBC(keys["AES-256"], iv[:]).Encrypt([]byte(message))
+		require.NoError(t, err)
+		assert.Equal(t, expected, encrypted)
+	})
+}
+
+func BenchmarkCBCDecrypt(b *testing.B) {
+	cbc := block.NewCBC(keys["AES-128"], make([]byte, 16))
+	for _, size := range []int{16, 1 << 20} {
+		benchmarkCPUs(b, size, func(b *testing.B, message []byte) {
+			encrypted, err := cbc.Encrypt(message[:size-1])
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := cbc.Decrypt(encrypted); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
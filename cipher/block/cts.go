@@ -0,0 +1,107 @@
+package block
+
+// CTS implements CBC with ciphertext stealing with AES, as the CBC-CS2 variant
+// of NIST SP 800-38A's addendum: messages aren't padded and ciphertexts are
+// exactly as long as plaintexts.
+// When the last block is partial, the last two ciphertext blocks are swapped
+// and the second to last one is truncated; otherwise it is plain CBC.
+// Messages must be at least one block long.
+// The key size selects AES-128, AES-192 or AES-256.
+type CTS struct {
+	Key []byte
+	IV  []byte
+
+	schedule schedule
+}
+
+// NewCTS creates a new CBC-CTS encryptor with the given key and IV.
+func NewCTS(key []byte, iv []byte) *CTS {
+	return &CTS{Key: key, IV: iv}
+}
+
+// Encrypt the given message.
+// It returns an error if the key isn't a valid AES key, if the IV isn't one
+// block long or if the message is shorter than one block.
+func (c *CTS) Encrypt(message []byte) ([]byte, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	blockLen := b.BlockSize()
+	if len(message) < blockLen {
+		return nil, ErrTooShort
+	}
+
+	// Zero-pad the partial last block and encrypt with CBC.
+	tail := len(message) % blockLen
+	encrypted := make([]byte, len(message)+(blockLen-tail)%blockLen)
+	copy(encrypted, message)
+	NewCBCEncrypter(b, c.IV).CryptBlocks(encrypted, encrypted)
+	if tail == 0 {
+		return encrypted, nil
+	}
+
+	// Swap the last two blocks, dropping the end of the second to last one.
+	n := len(encrypted)
+	last := make([]byte, blockLen)
+	copy(last, encrypted[n-blockLen:])
+	copy(encrypted[n-blockLen:], encrypted[n-2*blockLen:n-2*blockLen+tail])
+	copy(encrypted[n-2*blockLen:], last)
+
+	return encrypted[:len(message)], nil
+}
+
+// Decrypt the given ciphertext.
+// It returns an error if the key isn't a valid AES key, if the IV isn't one
+// block long or if the ciphertext is shorter than one block.
+func (c *CTS) Decrypt(ciphertext []byte) ([]byte, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	blockLen := b.BlockSize()
+	if len(ciphertext) < blockLen {
+		return nil, ErrTooShort
+	}
+
+	decrypted := make([]byte, len(ciphertext))
+	tail := len(ciphertext) % blockLen
+	if tail == 0 {
+		NewCBCDecrypter(b, c.IV).CryptBlocks(decrypted, ciphertext)
+		return decrypted, nil
+	}
+
+	// The blocks before the last two are plain CBC.
+	full := len(ciphertext) - tail - blockLen
+	prev := c.IV
+	if full > 0 {
+		NewCBCDecrypter(b, c.IV).CryptBlocks(decrypted[:full], ciphertext[:full])
+		prev = ciphertext[full-blockLen : full]
+	}
+
+	// The full block that comes first is the last CBC block, encrypted over
+	// the zero-padded partial block: decrypting it gives the partial plaintext
+	// XOR-ed with the partial block that follows, and the stolen end of that
+	// block.
+	lastBlock := ciphertext[full : full+blockLen]
+	partial := ciphertext[full+blockLen:]
+
+	d := make([]byte, blockLen)
+	b.Decrypt(d, lastBlock)
+
+	stolen := make([]byte, blockLen)
+	copy(stolen, partial)
+	copy(stolen[tail:], d[tail:])
+	for i := 0; i < tail; i++ {
+		decrypted[full+blockLen+i] = d[i] ^ partial[i]
+	}
+
+	b.Decrypt(d, stolen)
+	for i := 0; i < blockLen; i++ {
+		decrypted[full+i] = d[i] ^ prev[i]
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,52 @@
+package block_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+)
+
+func TestCTS(t *testing.T) {
+	key := keys["AES-128"]
+	iv := []byte("0123456789abcdef")
+	message := []byte("La musique souvent me prend comme une mer! Vers ma pale etoile")
+
+	b, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	for _, size := range []int{16, 17, 31, 32, 33, 47, 61} {
+		cts := block.NewCTS(key, iv)
+		encrypted, err := cts.Encrypt(message[:size])
+		require.NoError(t, err)
+		assert.Len(t, encrypted, size)
+
+		// Compare with CBC on the zero-padded message: the last two blocks are
+		// swapped and truncated when the last one is partial.
+		padded := make([]byte, (size+15)/16*16)
+		copy(padded, message[:size])
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(padded, padded)
+		if size%16 == 0 {
+			assert.Equal(t, padded, encrypted)
+		} else {
+			n := len(padded)
+			expected := append([]byte{}, padded[:n-32]...)
+			expected = append(expected, padded[n-16:]...)
+			expected = append(expected, padded[n-32:n-32+size%16]...)
+			assert.Equal(t, expected, encrypted)
+		}
+
+		decrypted, err := cts.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, message[:size], decrypted)
+	}
+
+	_, err = block.NewCTS(key, iv).Encrypt(message[:15])
+	assert.Equal(t, block.ErrTooShort, err)
+
+	_, err = block.NewCTS(key, iv).Decrypt(message[:15])
+	assert.Equal(t, block.ErrTooShort, err)
+}
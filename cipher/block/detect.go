@@ -0,0 +1,116 @@
+package block
+
+import (
+	"math"
+	"sort"
+
+	"github.com/t-bast/cryptopals/internal/parallel"
+)
+
+// ECBDetection is the result of looking for ECB in a ciphertext.
+type ECBDetection struct {
+	// Offset of the block alignment with the most repeated blocks.
+	// It is non-zero when the ciphertext starts with a header that isn't a
+	// multiple of the block size.
+	Offset int
+	// Repeats is the number of blocks that repeat an earlier block at that
+	// alignment.
+	Repeats int
+	// Score is the fraction of blocks that are repeats, between 0 and 1.
+	Score float64
+	// Confidence that the repeats come from ECB rather than from chance,
+	// between 0 and 1: a single repeat is conclusive for 16-byte blocks, but
+	// 8-byte blocks collide by chance in large enough ciphertexts.
+	Confidence float64
+}
+
+// DetectECB counts the repeated blocks of the ciphertext at every alignment
+// and returns the one with the most repeats.
+// ECB encrypts identical plaintext blocks to identical ciphertext blocks,
+// while other modes produce repeats with negligible probability.
+func DetectECB(ciphertext []byte, blockSize int) ECBDetection {
+	var best ECBDetection
+	blocks := 0
+	for offset := 0; offset < blockSize; offset++ {
+		seen := make(map[string]struct{})
+		repeats, n := 0, 0
+		for start := offset; start+blockSize <= len(ciphertext); start += blockSize {
+			n++
+			if _, ok := seen[string(ciphertext[start:start+blockSize])]; ok {
+				repeats++
+			} else {
+				seen[string(ciphertext[start:start+blockSize])] = struct{}{}
+			}
+		}
+
+		if offset == 0 {
+			blocks = n
+		}
+
+		if repeats > best.Repeats {
+			best = ECBDetection{
+				Offset:  offset,
+				Repeats: repeats,
+				Score:   float64(repeats) / float64(n),
+			}
+		}
+	}
+
+	if best.Repeats > 0 {
+		best.Confidence = ecbConfidence(blocks, blockSize, best.Repeats)
+	}
+
+	return best
+}
+
+// ecbConfidence returns one minus the probability that random blocks contain
+// that many repeats at one of the alignments.
+// The number of colliding pairs in random blocks roughly follows a Poisson
+// distribution, whose tail is dominated by its first term when collisions
+// are unlikely.
+func ecbConfidence(blocks, blockSize, repeats int) float64 {
+	pairs := float64(blocks) * float64(blocks-1) / 2
+	logLambda := math.Log(pairs*float64(blockSize)) - float64(8*blockSize)*math.Ln2
+	if logLambda >= 0 {
+		return 0
+	}
+
+	logFactorial, _ := math.Lgamma(float64(repeats + 1))
+	chance := math.Exp(float64(repeats)*logLambda - logFactorial)
+
+	return math.Max(0, 1-chance)
+}
+
+// RankedECB is the ECB detection of a ciphertext of a corpus.
+type RankedECB struct {
+	// Index of the ciphertext in the corpus.
+	Index int
+	ECBDetection
+}
+
+// minParallelCiphertexts is the minimum number of ciphertexts each goroutine
+// scans in RankECB.
+const minParallelCiphertexts = 64
+
+// RankECB runs DetectECB on every ciphertext and returns them ranked by
+// score, most likely ECB first.
+// Ties are ranked by number of repeats, then by corpus order.
+func RankECB(ciphertexts [][]byte, blockSize int) []RankedECB {
+	ranked := make([]RankedECB, len(ciphertexts))
+	bounds := parallel.Split(len(ciphertexts), minParallelCiphertexts)
+	parallel.Run(bounds, func(_, start, end int) {
+		for i := start; i < end; i++ {
+			ranked[i] = RankedECB{Index: i, ECBDetection: DetectECB(ciphertexts[i], blockSize)}
+		}
+	})
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+
+		return ranked[i].Repeats > ranked[j].Repeats
+	})
+
+	return ranked
+}
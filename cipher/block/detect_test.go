@@ -0,0 +1,83 @@
+package block_test
+
+import (
+	"crypto/des"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+)
+
+func TestDetectECB(t *testing.T) {
+	message := []byte(strings.Repeat("YELLOW SUBMARINE", 4) + "Who can ever satisfy any heart like mine?")
+
+	t.Run("AES", func(t *testing.T) {
+		encrypted, err := block.NewECB(keys["AES-128"]).Encrypt(message)
+		require.NoError(t, err)
+
+		d := block.DetectECB(encrypted, 16)
+		assert.Equal(t, 0, d.Offset)
+		assert.Equal(t, 3, d.Repeats)
+		assert.InDelta(t, 3.0/7, d.Score, 1e-9)
+		assert.InDelta(t, 1, d.Confidence, 1e-9)
+	})
+
+	t.Run("unaligned header", func(t *testing.T) {
+		encrypted, err := block.NewECB(keys["AES-128"]).Encrypt(message)
+		require.NoError(t, err)
+
+		d := block.DetectECB(append([]byte("HDR:\x05"), encrypted...), 16)
+		assert.Equal(t, 5, d.Offset)
+		assert.Equal(t, 3, d.Repeats)
+	})
+
+	t.Run("DES", func(t *testing.T) {
+		b, err := des.NewCipher([]byte("SUBMARIN"))
+		require.NoError(t, err)
+
+		encrypted := make([]byte, len(message)/8*8)
+		block.NewECBEncrypter(b).CryptBlocks(encrypted, message[:len(encrypted)])
+
+		d := block.DetectECB(encrypted, 8)
+		assert.Equal(t, 6, d.Repeats)
+		assert.Greater(t, d.Confidence, 0.99)
+	})
+
+	t.Run("CBC", func(t *testing.T) {
+		encrypted, err := block.NewCBC(keys["AES-128"], make([]byte, 16)).Encrypt(message)
+		require.NoError(t, err)
+
+		assert.Equal(t, block.ECBDetection{}, block.DetectECB(encrypted, 16))
+	})
+
+	t.Run("short", func(t *testing.T) {
+		assert.Equal(t, block.ECBDetection{}, block.DetectECB([]byte("YELLOW"), 16))
+	})
+}
+
+func TestRankECB(t *testing.T) {
+	message := []byte(strings.Repeat("YELLOW SUBMARINE", 3))
+	ecb, err := block.NewECB(keys["AES-128"]).Encrypt(message)
+	require.NoError(t, err)
+	cbc, err := block.NewCBC(keys["AES-128"], make([]byte, 16)).Encrypt(message)
+	require.NoError(t, err)
+
+	corpus := [][]byte{cbc, cbc, ecb[:32], cbc, ecb}
+	for i := 0; i < 3; i++ {
+		random := make([]byte, 64)
+		rand.Read(random)
+		corpus = append(corpus, random)
+	}
+
+	ranked := block.RankECB(corpus, 16)
+	require.Len(t, ranked, len(corpus))
+	assert.Equal(t, 4, ranked[0].Index)
+	assert.Equal(t, 2, ranked[0].Repeats)
+	assert.Equal(t, 2, ranked[1].Index)
+	assert.Equal(t, 1, ranked[1].Repeats)
+	assert.Equal(t, 0, ranked[2].Index)
+	assert.Equal(t, 0, ranked[2].Repeats)
+}
@@ -1,14 +1,19 @@
 package block
 
 import (
-	"crypto/aes"
+	"crypto/cipher"
 
 	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
 // ECB implements ECB encryption with AES.
+// The key size selects AES-128, AES-192 or AES-256.
 type ECB struct {
 	Key []byte
+	// Padding scheme used by Encrypt and Decrypt, PKCS#7 if nil.
+	Padding padding.Scheme
+
+	schedule schedule
 }
 
 // NewECB creates a new ECB encryptor with the given key.
@@ -16,40 +21,78 @@ func NewECB(key []byte) *ECB {
 	return &ECB{Key: key}
 }
 
-// Encrypt encrypts the given message.
-func (e *ECB) Encrypt(message []byte) []byte {
-	b, err := aes.NewCipher(e.Key)
+// Encrypter returns a cipher.BlockMode that encrypts with the key, without
+// padding.
+func (e *ECB) Encrypter() (cipher.BlockMode, error) {
+	b, err := e.schedule.block(e.Key)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	paddedMsg := padding.PKCS7(message, len(e.Key))
+	return NewECBEncrypter(b), nil
+}
 
-	encrypted := make([]byte, len(paddedMsg))
-	for i := 0; i < len(paddedMsg)/len(e.Key); i++ {
-		start := i * len(e.Key)
-		end := start + len(e.Key)
-		b.Encrypt(encrypted[start:end], paddedMsg[start:end])
+// Decrypter returns a cipher.BlockMode that decrypts with the key, without
+// removing padding.
+func (e *ECB) Decrypter() (cipher.BlockMode, error) {
+	b, err := e.schedule.block(e.Key)
+	if err != nil {
+		return nil, err
 	}
 
-	return encrypted
+	return NewECBDecrypter(b), nil
+}
+
+// Encrypt encrypts the given message.
+// It returns an error if the key isn't a valid AES key.
+func (e *ECB) Encrypt(message []byte) ([]byte, error) {
+	m, err := e.Encrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptBlocks(m, message, e.Padding), nil
 }
 
 // Decrypt decrypts the given ciphertext.
-func (e *ECB) Decrypt(ciphertext []byte) []byte {
-	b, err := aes.NewCipher(e.Key)
+// It returns an error if the key isn't a valid AES key, if the ciphertext
+// isn't made of full blocks or if its padding is invalid.
+func (e *ECB) Decrypt(ciphertext []byte) ([]byte, error) {
+	m, err := e.Decrypter()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	decrypted := make([]byte, len(ciphertext))
-	for i := 0; i < len(ciphertext)/len(e.Key); i++ {
-		start := i * len(e.Key)
-		end := start + len(e.Key)
-		b.Decrypt(decrypted[start:end], ciphertext[start:end])
+	return decryptBlocks(m, ciphertext, e.Padding)
+}
+
+// encryptBlocks pads the message with the scheme, PKCS#7 if nil, and
+// encrypts it.
+func encryptBlocks(m cipher.BlockMode, message []byte, s padding.Scheme) []byte {
+	if s == nil {
+		s = padding.SchemePKCS7
 	}
 
-	msg := padding.UnPKCS7(decrypted, len(e.Key))
+	encrypted := s.Pad(message, m.BlockSize())
+	m.CryptBlocks(encrypted, encrypted)
+
+	return encrypted
+}
+
+// decryptBlocks decrypts the ciphertext and removes its padding with the
+// scheme, PKCS#7 if nil.
+func decryptBlocks(m cipher.BlockMode, ciphertext []byte, s padding.Scheme) ([]byte, error) {
+	if s == nil {
+		s = padding.SchemePKCS7
+	}
+
+	blockLen := m.BlockSize()
+	if len(ciphertext) == 0 || len(ciphertext)%blockLen != 0 {
+		return nil, ErrInvalidLength
+	}
+
+	decrypted := make([]byte, len(ciphertext))
+	m.CryptBlocks(decrypted, ciphertext)
 
-	return msg
+	return s.Unpad(decrypted, blockLen)
 }
@@ -1,22 +1,81 @@
 package block_test
 
 import (
+	"crypto/aes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
+var keys = map[string][]byte{
+	"AES-128": []byte("YELLOW SUBMARINE"),
+	"AES-192": []byte("YELLOW SUBMARINE PURPLE "),
+	"AES-256": []byte("YELLOW SUBMARINE PURPLE SUNRISE!"),
+}
+
 func TestECB(t *testing.T) {
-	key := "YELLOW SUBMARINE"
-	ecb := block.NewECB([]byte(key))
 	message := "If rape, poison, dagger and fire," +
 		"Have still not embroidered their pleasant designs" +
 		"On the banal canvas of our pitiable destinies," +
 		"It's because our soul, alas, is not bold enough!"
 
-	encrypted := ecb.Encrypt([]byte(message))
-	decrypted := ecb.Decrypt(encrypted)
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			ecb := block.NewECB(key)
+			encrypted, err := ecb.Encrypt([]byte(message))
+			require.NoError(t, err)
+			assert.Len(t, encrypted, 192)
+
+			// The block size doesn't depend on the key size.
+			b, err := aes.NewCipher(key)
+			require.NoError(t, err)
+			expected := make([]byte, aes.BlockSize)
+			b.Encrypt(expected, []byte(message[:aes.BlockSize]))
+			assert.Equal(t, expected, encrypted[:aes.BlockSize])
+
+			decrypted, err := ecb.Decrypt(encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, message, string(decrypted))
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := block.NewECB([]byte("YELLOW")).Encrypt([]byte(message))
+		assert.Equal(t, aes.KeySizeError(6), err)
+
+		_, err = block.NewECB([]byte("YELLOW")).Decrypt(make([]byte, 16))
+		assert.Equal(t, aes.KeySizeError(6), err)
+	})
+
+	t.Run("invalid ciphertext", func(t *testing.T) {
+		ecb := block.NewECB(keys["AES-128"])
+		encrypted, err := ecb.Encrypt([]byte(message))
+		require.NoError(t, err)
+
+		_, err = ecb.Decrypt(encrypted[:100])
+		assert.Equal(t, block.ErrInvalidLength, err)
+
+		_, err = ecb.Decrypt(nil)
+		assert.Equal(t, block.ErrInvalidLength, err)
+
+		// The last block is a text block instead of padding.
+		_, err = ecb.Decrypt(append(encrypted[:16], encrypted[:16]...))
+		assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+	})
+}
 
-	assert.Equal(t, message, string(decrypted))
+func BenchmarkECB(b *testing.B) {
+	ecb := block.NewECB(keys["AES-128"])
+	for _, size := range []int{16, 1 << 20} {
+		benchmarkCPUs(b, size, func(b *testing.B, message []byte) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ecb.Encrypt(message); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,192 @@
+package block
+
+import (
+	"crypto/cipher"
+	"io"
+)
+
+// CFB implements the cipher feedback mode with AES, with full-block
+// feedback (CFB128): each ciphertext block is encrypted to produce the
+// keystream for the next one.
+// It turns the block cipher into a self-synchronizing stream cipher, so
+// messages aren't padded.
+// The key size selects AES-128, AES-192 or AES-256.
+type CFB struct {
+	Key []byte
+	IV  []byte
+
+	schedule schedule
+}
+
+// NewCFB creates a new CFB encryptor with the given key and IV.
+func NewCFB(key []byte, iv []byte) *CFB {
+	return &CFB{Key: key, IV: iv}
+}
+
+// Encrypter returns a cipher.Stream that encrypts with the key and IV.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CFB) Encrypter() (cipher.Stream, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCFB(b, c.IV, false), nil
+}
+
+// Decrypter returns a cipher.Stream that decrypts with the key and IV.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CFB) Decrypter() (cipher.Stream, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCFB(b, c.IV, true), nil
+}
+
+// Encrypt the given message.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CFB) Encrypt(message []byte) ([]byte, error) {
+	return xorStream(c.Encrypter, message)
+}
+
+// Decrypt the given ciphertext.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *CFB) Decrypt(ciphertext []byte) ([]byte, error) {
+	return xorStream(c.Decrypter, ciphertext)
+}
+
+// OFB implements the output feedback mode with AES: the IV is encrypted
+// repeatedly to produce a keystream that doesn't depend on the message.
+// Messages aren't padded.
+// The key size selects AES-128, AES-192 or AES-256.
+type OFB struct {
+	Key []byte
+	IV  []byte
+
+	schedule schedule
+}
+
+// NewOFB creates a new OFB encryptor with the given key and IV.
+func NewOFB(key []byte, iv []byte) *OFB {
+	return &OFB{Key: key, IV: iv}
+}
+
+// Stream returns the keystream as a cipher.Stream: encryption and decryption
+// are the same operation.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *OFB) Stream() (cipher.Stream, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOFB(b, c.IV), nil
+}
+
+// Encrypt the given message.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *OFB) Encrypt(message []byte) ([]byte, error) {
+	return xorStream(c.Stream, message)
+}
+
+// Decrypt the given ciphertext.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *OFB) Decrypt(ciphertext []byte) ([]byte, error) {
+	return xorStream(c.Stream, ciphertext)
+}
+
+func xorStream(newStream func() (cipher.Stream, error), src []byte) ([]byte, error) {
+	s, err := newStream()
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, len(src))
+	s.XORKeyStream(dst, src)
+
+	return dst, nil
+}
+
+// cfb implements CFB as a cipher.Stream.
+type cfb struct {
+	b       cipher.Block
+	decrypt bool
+	// next holds the ciphertext block being built, which is encrypted to get
+	// the keystream once complete.
+	next      []byte
+	keystream []byte
+	used      int
+}
+
+func newCFB(b cipher.Block, iv []byte, decrypt bool) *cfb {
+	keystream := make([]byte, b.BlockSize())
+	b.Encrypt(keystream, iv)
+
+	return &cfb{
+		b:         b,
+		decrypt:   decrypt,
+		next:      make([]byte, b.BlockSize()),
+		keystream: keystream,
+	}
+}
+
+func (s *cfb) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+
+	for i, c := range src {
+		if s.used == len(s.keystream) {
+			s.b.Encrypt(s.keystream, s.next)
+			s.used = 0
+		}
+
+		dst[i] = c ^ s.keystream[s.used]
+		if s.decrypt {
+			s.next[s.used] = c
+		} else {
+			s.next[s.used] = dst[i]
+		}
+
+		s.used++
+	}
+}
+
+// ofb implements OFB as a cipher.Stream.
+type ofb struct {
+	b         cipher.Block
+	keystream []byte
+	used      int
+}
+
+func newOFB(b cipher.Block, iv []byte) *ofb {
+	keystream := make([]byte, len(iv))
+	copy(keystream, iv)
+
+	return &ofb{b: b, keystream: keystream, used: len(keystream)}
+}
+
+func (s *ofb) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+
+	for i, c := range src {
+		if s.used == len(s.keystream) {
+			s.b.Encrypt(s.keystream, s.keystream)
+			s.used = 0
+		}
+
+		dst[i] = c ^ s.keystream[s.used]
+		s.used++
+	}
+}
@@ -0,0 +1,197 @@
+package block
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/t-bast/cryptopals/cipher/padding"
+)
+
+// ErrClosed is returned when writing to a closed writer.
+var ErrClosed = errors.New("write to closed writer")
+
+// readChunkBlocks is the number of blocks read from the underlying reader at
+// once.
+const readChunkBlocks = 256
+
+// NewEncryptReader returns a reader that encrypts everything read from r with
+// the block mode.
+// The plaintext is padded with PKCS#7 when r reaches EOF.
+func NewEncryptReader(r io.Reader, m cipher.BlockMode) io.Reader {
+	return &blockReader{r: r, p: newEncryptProcessor(m)}
+}
+
+// NewDecryptReader returns a reader that decrypts everything read from r with
+// the block mode.
+// The padding is removed when r reaches EOF: reading then fails if the
+// ciphertext isn't made of full blocks or if its padding is invalid.
+func NewDecryptReader(r io.Reader, m cipher.BlockMode) io.Reader {
+	return &blockReader{r: r, p: newDecryptProcessor(m)}
+}
+
+// NewEncryptWriter returns a writer that encrypts everything with the block
+// mode before writing it to w.
+// Data is written in full blocks: Close pads and writes the last block, and
+// closes w if w is an io.Closer.
+func NewEncryptWriter(w io.Writer, m cipher.BlockMode) io.WriteCloser {
+	return &blockWriter{w: w, p: newEncryptProcessor(m)}
+}
+
+// NewDecryptWriter returns a writer that decrypts everything with the block
+// mode before writing it to w.
+// The last block is only written on Close, without padding: Close fails if
+// the ciphertext isn't made of full blocks or if its padding is invalid.
+// It closes w if w is an io.Closer.
+func NewDecryptWriter(w io.Writer, m cipher.BlockMode) io.WriteCloser {
+	return &blockWriter{w: w, p: newDecryptProcessor(m)}
+}
+
+// processor encrypts or decrypts data as it comes, one full block at a time.
+type processor struct {
+	m cipher.BlockMode
+	// pending input that doesn't fill a block yet.
+	pending []byte
+	// holdBack keeps the last full block in pending until the end of the
+	// input, because it contains the padding.
+	holdBack bool
+	finish   func(last []byte) ([]byte, error)
+}
+
+func newEncryptProcessor(m cipher.BlockMode) *processor {
+	return &processor{
+		m: m,
+		finish: func(last []byte) ([]byte, error) {
+			padded := padding.PKCS7(last, m.BlockSize())
+			m.CryptBlocks(padded, padded)
+			return padded, nil
+		},
+	}
+}
+
+func newDecryptProcessor(m cipher.BlockMode) *processor {
+	return &processor{
+		m:        m,
+		holdBack: true,
+		finish: func(last []byte) ([]byte, error) {
+			if len(last) != m.BlockSize() {
+				return nil, ErrInvalidLength
+			}
+
+			decrypted := make([]byte, len(last))
+			m.CryptBlocks(decrypted, last)
+			return padding.UnPKCS7(decrypted, m.BlockSize())
+		},
+	}
+}
+
+// process adds the input and returns the output of all full blocks it can
+// process yet, appended to dst.
+func (p *processor) process(dst, input []byte) []byte {
+	p.pending = append(p.pending, input...)
+
+	blockLen := p.m.BlockSize()
+	ready := len(p.pending) / blockLen * blockLen
+	if p.holdBack && ready == len(p.pending) {
+		ready -= blockLen
+	}
+
+	if ready <= 0 {
+		return dst
+	}
+
+	start := len(dst)
+	dst = append(dst, p.pending[:ready]...)
+	p.m.CryptBlocks(dst[start:], dst[start:])
+
+	p.pending = p.pending[:copy(p.pending, p.pending[ready:])]
+	return dst
+}
+
+type blockReader struct {
+	r   io.Reader
+	p   *processor
+	buf []byte
+	out []byte
+	err error
+}
+
+func (r *blockReader) Read(b []byte) (int, error) {
+	for len(r.out) == 0 && r.err == nil {
+		r.fill()
+	}
+
+	n := copy(b, r.out)
+	r.out = r.out[n:]
+	if len(r.out) > 0 {
+		return n, nil
+	}
+
+	return n, r.err
+}
+
+// fill reads the next chunk from the underlying reader and processes it.
+func (r *blockReader) fill() {
+	if r.buf == nil {
+		r.buf = make([]byte, readChunkBlocks*r.p.m.BlockSize())
+	}
+
+	n, err := r.r.Read(r.buf)
+	r.out = r.p.process(r.out[:0], r.buf[:n])
+	if err == io.EOF {
+		last, ferr := r.p.finish(r.p.pending)
+		r.out = append(r.out, last...)
+		r.err = io.EOF
+		if ferr != nil {
+			r.err = ferr
+		}
+	} else if err != nil {
+		r.err = err
+	}
+}
+
+type blockWriter struct {
+	w      io.Writer
+	p      *processor
+	out    []byte
+	closed bool
+}
+
+func (w *blockWriter) Write(b []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	w.out = w.p.process(w.out[:0], b)
+	if len(w.out) > 0 {
+		if _, err := w.w.Write(w.out); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Close writes the last block and closes the underlying writer if it is an
+// io.Closer.
+func (w *blockWriter) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+
+	w.closed = true
+	last, err := w.p.finish(w.p.pending)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(last); err != nil {
+		return err
+	}
+
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package block_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
+)
+
+func TestStreams(t *testing.T) {
+	cbc := block.NewCBC(keys["AES-256"], []byte("0123456789abcdef"))
+	newMode := func(encrypt bool) cipher.BlockMode {
+		var m cipher.BlockMode
+		var err error
+		if encrypt {
+			m, err = cbc.Encrypter()
+		} else {
+			m, err = cbc.Decrypter()
+		}
+
+		require.NoError(t, err)
+		return m
+	}
+
+	for _, size := range []int{0, 1, 15, 16, 17, 4096, 100000} {
+		message := []byte(strings.Repeat("Un soir, l'ame du vin chantait dans les bouteilles.", size/50+1)[:size])
+		expected, err := cbc.Encrypt(message)
+		require.NoError(t, err)
+
+		// Write in chunks that don't match the block size.
+		var encrypted bytes.Buffer
+		w := block.NewEncryptWriter(&encrypted, newMode(true))
+		for i := 0; i < len(message); i += 7 {
+			end := i + 7
+			if end > len(message) {
+				end = len(message)
+			}
+
+			_, err := w.Write(message[i:end])
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, w.Close())
+		assert.Equal(t, expected, encrypted.Bytes())
+
+		encryptedFromReader, err := ioutil.ReadAll(block.NewEncryptReader(iotest.OneByteReader(bytes.NewReader(message)), newMode(true)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, encryptedFromReader)
+
+		decrypted, err := ioutil.ReadAll(block.NewDecryptReader(iotest.HalfReader(bytes.NewReader(expected)), newMode(false)))
+		require.NoError(t, err)
+		assert.Equal(t, string(message), string(decrypted))
+
+		var decryptedFromWriter bytes.Buffer
+		w = block.NewDecryptWriter(&decryptedFromWriter, newMode(false))
+		_, err = io.Copy(w, iotest.OneByteReader(bytes.NewReader(expected)))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.Equal(t, string(message), decryptedFromWriter.String())
+	}
+
+	t.Run("invalid ciphertext", func(t *testing.T) {
+		encrypted, err := cbc.Encrypt([]byte("Le vin des chiffonniers"))
+		require.NoError(t, err)
+
+		_, err = ioutil.ReadAll(block.NewDecryptReader(bytes.NewReader(encrypted[:20]), newMode(false)))
+		assert.Equal(t, block.ErrInvalidLength, err)
+
+		_, err = ioutil.ReadAll(block.NewDecryptReader(bytes.NewReader(nil), newMode(false)))
+		assert.Equal(t, block.ErrInvalidLength, err)
+
+		encrypted[len(encrypted)-17] ^= 0x42
+		_, err = ioutil.ReadAll(block.NewDecryptReader(bytes.NewReader(encrypted), newMode(false)))
+		assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+
+		w := block.NewDecryptWriter(ioutil.Discard, newMode(false))
+		_, err = w.Write(encrypted)
+		require.NoError(t, err)
+		assert.ErrorIs(t, w.Close(), padding.ErrInvalidPadding)
+		_, err = w.Write(encrypted)
+		assert.Equal(t, block.ErrClosed, err)
+	})
+}
@@ -0,0 +1,190 @@
+package block
+
+import (
+	"crypto/cipher"
+	"io"
+
+	"github.com/t-bast/cryptopals/internal/parallel"
+)
+
+// minParallelBlocks is the minimum number of blocks each goroutine processes
+// when a mode runs in parallel: below that, goroutines cost more than they
+// save.
+const minParallelBlocks = 1024
+
+// ecb implements ECB as a cipher.BlockMode.
+type ecb struct {
+	b       cipher.Block
+	encrypt bool
+}
+
+// NewECBEncrypter returns a cipher.BlockMode which encrypts in ECB mode with
+// the given block cipher.
+func NewECBEncrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b, encrypt: true}
+}
+
+// NewECBDecrypter returns a cipher.BlockMode which decrypts in ECB mode with
+// the given block cipher.
+func NewECBDecrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b}
+}
+
+// BlockSize returns the block size of the underlying block cipher.
+func (m *ecb) BlockSize() int {
+	return m.b.BlockSize()
+}
+
+// CryptBlocks encrypts or decrypts a number of blocks.
+// It panics if src isn't made of full blocks or if dst is smaller than src.
+// Large inputs are processed in parallel.
+func (m *ecb) CryptBlocks(dst, src []byte) {
+	blockLen := m.b.BlockSize()
+	checkBlocks(dst, src, blockLen)
+
+	bounds := parallel.Split(len(src)/blockLen, minParallelBlocks)
+	parallel.Run(bounds, func(_, first, last int) {
+		for start := first * blockLen; start < last*blockLen; start += blockLen {
+			end := start + blockLen
+			if m.encrypt {
+				m.b.Encrypt(dst[start:end], src[start:end])
+			} else {
+				m.b.Decrypt(dst[start:end], src[start:end])
+			}
+		}
+	})
+}
+
+// cbcEncrypter implements CBC encryption as a cipher.BlockMode.
+type cbcEncrypter struct {
+	b cipher.Block
+	v []byte
+}
+
+// NewCBCEncrypter returns a cipher.BlockMode which encrypts in CBC mode with
+// the given block cipher and IV.
+// The IV must be one block long: it is copied, and successive calls to
+// CryptBlocks continue the chain.
+func NewCBCEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic(ErrInvalidIV)
+	}
+
+	v := make([]byte, len(iv))
+	copy(v, iv)
+
+	return &cbcEncrypter{b: b, v: v}
+}
+
+// BlockSize returns the block size of the underlying block cipher.
+func (m *cbcEncrypter) BlockSize() int {
+	return m.b.BlockSize()
+}
+
+// CryptBlocks encrypts a number of blocks.
+// It panics if src isn't made of full blocks or if dst is smaller than src.
+func (m *cbcEncrypter) CryptBlocks(dst, src []byte) {
+	blockLen := m.b.BlockSize()
+	checkBlocks(dst, src, blockLen)
+
+	for start := 0; start < len(src); start += blockLen {
+		end := start + blockLen
+		for i := range m.v {
+			m.v[i] ^= src[start+i]
+		}
+
+		m.b.Encrypt(dst[start:end], m.v)
+		copy(m.v, dst[start:end])
+	}
+}
+
+// cbcDecrypter implements CBC decryption as a cipher.BlockMode.
+type cbcDecrypter struct {
+	b     cipher.Block
+	v     []byte
+	block []byte
+}
+
+// NewCBCDecrypter returns a cipher.BlockMode which decrypts in CBC mode with
+// the given block cipher and IV.
+// The IV must be one block long: it is copied, and successive calls to
+// CryptBlocks continue the chain.
+func NewCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic(ErrInvalidIV)
+	}
+
+	v := make([]byte, len(iv))
+	copy(v, iv)
+
+	return &cbcDecrypter{b: b, v: v, block: make([]byte, len(iv))}
+}
+
+// BlockSize returns the block size of the underlying block cipher.
+func (m *cbcDecrypter) BlockSize() int {
+	return m.b.BlockSize()
+}
+
+// CryptBlocks decrypts a number of blocks.
+// It panics if src isn't made of full blocks or if dst is smaller than src.
+// dst and src may overlap entirely.
+// Large inputs are decrypted in parallel: each block only depends on its
+// ciphertext and the previous one.
+func (m *cbcDecrypter) CryptBlocks(dst, src []byte) {
+	blockLen := m.b.BlockSize()
+	checkBlocks(dst, src, blockLen)
+
+	bounds := parallel.Split(len(src)/blockLen, minParallelBlocks)
+	if len(bounds) == 2 {
+		cbcDecrypt(m.b, dst, src, m.v, m.block)
+		return
+	}
+
+	// Every range starts from the ciphertext block before it, which must be
+	// saved before it's overwritten when decrypting in place.
+	ranges := len(bounds) - 1
+	vs := make([]byte, ranges*blockLen)
+	copy(vs, m.v)
+	for i := 1; i < ranges; i++ {
+		copy(vs[i*blockLen:], src[(bounds[i]-1)*blockLen:bounds[i]*blockLen])
+	}
+
+	copy(m.v, src[len(src)-blockLen:])
+	scratch := make([]byte, ranges*blockLen)
+
+	parallel.Run(bounds, func(i, first, last int) {
+		cbcDecrypt(m.b,
+			dst[first*blockLen:last*blockLen],
+			src[first*blockLen:last*blockLen],
+			vs[i*blockLen:(i+1)*blockLen],
+			scratch[i*blockLen:(i+1)*blockLen])
+	})
+}
+
+// cbcDecrypt decrypts blocks chained from v, using block as scratch space.
+// v is updated with the last ciphertext block.
+func cbcDecrypt(b cipher.Block, dst, src, v, block []byte) {
+	blockLen := len(v)
+	for start := 0; start < len(src); start += blockLen {
+		end := start + blockLen
+		b.Decrypt(block, src[start:end])
+		for i := range block {
+			block[i] ^= v[i]
+		}
+
+		// Keep the ciphertext block before overwriting it when decrypting in
+		// place.
+		copy(v, src[start:end])
+		copy(dst[start:end], block)
+	}
+}
+
+func checkBlocks(dst, src []byte, blockLen int) {
+	if len(src)%blockLen != 0 {
+		panic(ErrInvalidLength)
+	}
+
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+}
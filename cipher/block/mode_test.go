@@ -0,0 +1,112 @@
+package block_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/internal/paralleltest"
+)
+
+func TestBlockModes(t *testing.T) {
+	b, err := aes.NewCipher(keys["AES-128"])
+	require.NoError(t, err)
+
+	iv := []byte("0123456789abcdef")
+	plaintext := []byte("Pour l'enfant, amoureux de cartes et d'estampes, L'univers est egal!")[:64]
+
+	t.Run("CBC matches the standard library", func(t *testing.T) {
+		expected := make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(expected, plaintext)
+
+		// Encrypting in several calls continues the chain.
+		enc := block.NewCBCEncrypter(b, iv)
+		assert.Equal(t, aes.BlockSize, enc.BlockSize())
+		encrypted := make([]byte, len(plaintext))
+		enc.CryptBlocks(encrypted[:16], plaintext[:16])
+		enc.CryptBlocks(encrypted[16:], plaintext[16:])
+		assert.Equal(t, expected, encrypted)
+
+		// Decrypting in place.
+		block.NewCBCDecrypter(b, iv).CryptBlocks(encrypted, encrypted)
+		assert.Equal(t, plaintext, encrypted)
+	})
+
+	t.Run("ECB encrypts blocks independently", func(t *testing.T) {
+		encrypted := make([]byte, len(plaintext))
+		block.NewECBEncrypter(b).CryptBlocks(encrypted, plaintext)
+		for i := 0; i < len(plaintext); i += aes.BlockSize {
+			expected := make([]byte, aes.BlockSize)
+			b.Encrypt(expected, plaintext[i:i+aes.BlockSize])
+			assert.Equal(t, expected, encrypted[i:i+aes.BlockSize])
+		}
+
+		decrypted := make([]byte, len(plaintext))
+		block.NewECBDecrypter(b).CryptBlocks(decrypted, encrypted)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		assert.Panics(t, func() { block.NewCBCEncrypter(b, iv[:8]) })
+		assert.Panics(t, func() { block.NewECBEncrypter(b).CryptBlocks(make([]byte, 32), plaintext[:20]) })
+		assert.Panics(t, func() { block.NewECBEncrypter(b).CryptBlocks(make([]byte, 16), plaintext[:32]) })
+	})
+}
+
+func TestBlockModesParallel(t *testing.T) {
+	// Force several goroutines even on a single CPU.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	b, err := aes.NewCipher(keys["AES-256"])
+	require.NoError(t, err)
+
+	iv := []byte("0123456789abcdef")
+	plaintext := make([]byte, 10000*aes.BlockSize)
+	for i := range plaintext {
+		plaintext[i] = byte(i * 7)
+	}
+
+	t.Run("ECB", func(t *testing.T) {
+		encrypted := make([]byte, len(plaintext))
+		block.NewECBEncrypter(b).CryptBlocks(encrypted, plaintext)
+		for _, i := range []int{0, 2500, 5000, 7499, 7500, 9999} {
+			expected := make([]byte, aes.BlockSize)
+			b.Encrypt(expected, plaintext[i*aes.BlockSize:(i+1)*aes.BlockSize])
+			assert.Equal(t, expected, encrypted[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		}
+
+		block.NewECBDecrypter(b).CryptBlocks(encrypted, encrypted)
+		assert.Equal(t, plaintext, encrypted)
+	})
+
+	t.Run("CBC decryption in place", func(t *testing.T) {
+		encrypted := make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(encrypted, plaintext)
+
+		// The chain continues across calls.
+		dec := block.NewCBCDecrypter(b, iv)
+		dec.CryptBlocks(encrypted[:5000*aes.BlockSize], encrypted[:5000*aes.BlockSize])
+		dec.CryptBlocks(encrypted[5000*aes.BlockSize:], encrypted[5000*aes.BlockSize:])
+		assert.Equal(t, plaintext, encrypted)
+	})
+}
+
+// benchmarkCPUs runs the benchmark on messages of the given size, on one CPU
+// and on all of them, to compare sequential and parallel throughput.
+func benchmarkCPUs(b *testing.B, size int, f func(b *testing.B, message []byte)) {
+	message := make([]byte, size)
+	for _, cpus := range paralleltest.BenchmarkCPUs() {
+		b.Run(fmt.Sprintf("%dB/%dCPU", size, cpus), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(cpus))
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			f(b, message)
+		})
+	}
+}
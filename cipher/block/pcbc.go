@@ -0,0 +1,127 @@
+package block
+
+import (
+	"crypto/cipher"
+
+	"github.com/t-bast/cryptopals/cipher/padding"
+)
+
+// PCBC implements propagating cipher block chaining with AES: each plaintext
+// block is XOR-ed with both the previous plaintext and ciphertext blocks before
+// being encrypted, so an error propagates to all following blocks.
+// The key size selects AES-128, AES-192 or AES-256.
+type PCBC struct {
+	Key []byte
+	IV  []byte
+	// Padding scheme used by Encrypt and Decrypt, PKCS#7 if nil.
+	Padding padding.Scheme
+
+	schedule schedule
+}
+
+// NewPCBC creates a new PCBC encryptor with the given key and IV.
+func NewPCBC(key []byte, iv []byte) *PCBC {
+	return &PCBC{Key: key, IV: iv}
+}
+
+// Encrypter returns a cipher.BlockMode that encrypts with the key and IV,
+// without padding.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *PCBC) Encrypter() (cipher.BlockMode, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPCBC(b, c.IV, true), nil
+}
+
+// Decrypter returns a cipher.BlockMode that decrypts with the key and IV,
+// without removing padding.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *PCBC) Decrypter() (cipher.BlockMode, error) {
+	b, err := c.schedule.newBlock(c.Key, c.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPCBC(b, c.IV, false), nil
+}
+
+// Encrypt the given message.
+// It returns an error if the key isn't a valid AES key or if the IV isn't one
+// block long.
+func (c *PCBC) Encrypt(message []byte) ([]byte, error) {
+	m, err := c.Encrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptBlocks(m, message, c.Padding), nil
+}
+
+// Decrypt the given ciphertext.
+// It returns an error if the key isn't a valid AES key, if the IV isn't one
+// block long, if the ciphertext isn't made of full blocks or if its padding is
+// invalid.
+func (c *PCBC) Decrypt(ciphertext []byte) ([]byte, error) {
+	m, err := c.Decrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptBlocks(m, ciphertext, c.Padding)
+}
+
+// pcbc implements PCBC as a cipher.BlockMode.
+type pcbc struct {
+	b       cipher.Block
+	encrypt bool
+	// v is the XOR of the previous plaintext and ciphertext blocks.
+	v     []byte
+	block []byte
+}
+
+func newPCBC(b cipher.Block, iv []byte, encrypt bool) *pcbc {
+	v := make([]byte, len(iv))
+	copy(v, iv)
+
+	return &pcbc{b: b, encrypt: encrypt, v: v, block: make([]byte, len(iv))}
+}
+
+func (m *pcbc) BlockSize() int {
+	return m.b.BlockSize()
+}
+
+func (m *pcbc) CryptBlocks(dst, src []byte) {
+	blockLen := m.b.BlockSize()
+	checkBlocks(dst, src, blockLen)
+
+	for start := 0; start < len(src); start += blockLen {
+		end := start + blockLen
+		if m.encrypt {
+			for i := range m.block {
+				m.block[i] = src[start+i] ^ m.v[i]
+			}
+
+			copy(m.v, src[start:end])
+			m.b.Encrypt(dst[start:end], m.block)
+			for i := range m.v {
+				m.v[i] ^= dst[start+i]
+			}
+		} else {
+			m.b.Decrypt(m.block, src[start:end])
+			for i := range m.block {
+				m.block[i] ^= m.v[i]
+			}
+
+			copy(m.v, src[start:end])
+			copy(dst[start:end], m.block)
+			for i := range m.v {
+				m.v[i] ^= m.block[i]
+			}
+		}
+	}
+}
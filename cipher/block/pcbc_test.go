@@ -0,0 +1,53 @@
+package block_test
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestPCBC(t *testing.T) {
+	iv := []byte("0123456789abcdef")
+	message := []byte("Homme libre, toujours tu cheriras la mer!")
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			pcbc := block.NewPCBC(key, iv)
+			encrypted, err := pcbc.Encrypt(message)
+			require.NoError(t, err)
+
+			// C_i = E(P_i ^ P_i-1 ^ C_i-1), with P_0 ^ C_0 = IV.
+			b, err := aes.NewCipher(key)
+			require.NoError(t, err)
+			padded := padding.PKCS7(message, aes.BlockSize)
+			v := iv
+			for i := 0; i < len(padded); i += aes.BlockSize {
+				expected := make([]byte, aes.BlockSize)
+				b.Encrypt(expected, xor.Bytes(padded[i:i+aes.BlockSize], v))
+				assert.Equal(t, expected, encrypted[i:i+aes.BlockSize])
+				v = xor.Bytes(padded[i:i+aes.BlockSize], expected)
+			}
+
+			decrypted, err := pcbc.Decrypt(encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, message, decrypted)
+		})
+	}
+
+	t.Run("errors propagate", func(t *testing.T) {
+		pcbc := block.NewPCBC(keys["AES-128"], iv)
+		encrypted, err := pcbc.Encrypt(message)
+		require.NoError(t, err)
+
+		// Unlike CBC, a flipped bit garbles every following block, including
+		// the padding.
+		encrypted[0] ^= 1
+		_, err = pcbc.Decrypt(encrypted)
+		assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+	})
+}
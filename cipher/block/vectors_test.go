@@ -0,0 +1,102 @@
+package block_test
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+)
+
+type vector struct {
+	name   string
+	fields map[string]string
+}
+
+func (v vector) bytes(t *testing.T, field string) []byte {
+	b, err := hex.DecodeString(v.fields[field])
+	require.NoError(t, err)
+	return b
+}
+
+// readVectors reads test vectors from a file made of [name] sections
+// followed by FIELD = value lines.
+func readVectors(t *testing.T, name string) []vector {
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var vectors []vector
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "["):
+			vectors = append(vectors, vector{
+				name:   strings.Trim(line, "[]"),
+				fields: make(map[string]string),
+			})
+		default:
+			parts := strings.SplitN(line, " = ", 2)
+			require.Len(t, parts, 2, line)
+			require.NotEmpty(t, vectors, line)
+			vectors[len(vectors)-1].fields[parts[0]] = parts[1]
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+	return vectors
+}
+
+func TestNISTVectors(t *testing.T) {
+	vectors := readVectors(t, "sp800-38a.txt")
+	require.Len(t, vectors, 9)
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			key, iv := v.bytes(t, "KEY"), v.bytes(t, "IV")
+			plaintext, ciphertext := v.bytes(t, "PLAINTEXT"), v.bytes(t, "CIPHERTEXT")
+
+			var c block.Cipher
+			switch v.fields["MODE"] {
+			case "CBC":
+				// The vectors don't use padding, which we can check with the
+				// block mode directly and with ciphertext stealing.
+				m, err := block.NewCBC(key, iv).Encrypter()
+				require.NoError(t, err)
+				encrypted := make([]byte, len(plaintext))
+				m.CryptBlocks(encrypted, plaintext)
+				assert.Equal(t, ciphertext, encrypted)
+
+				c = block.NewCTS(key, iv)
+			case "CFB":
+				c = block.NewCFB(key, iv)
+			case "OFB":
+				c = block.NewOFB(key, iv)
+			default:
+				require.Fail(t, "unknown mode", v.fields["MODE"])
+			}
+
+			encrypted, err := c.Encrypt(plaintext)
+			require.NoError(t, err)
+			assert.Equal(t, ciphertext, encrypted)
+
+			decrypted, err := c.Decrypt(ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+
+			// Stream modes also work on partial blocks.
+			if v.fields["MODE"] != "CBC" {
+				encrypted, err := c.Encrypt(plaintext[:37])
+				require.NoError(t, err)
+				assert.Equal(t, ciphertext[:37], encrypted)
+			}
+		})
+	}
+}
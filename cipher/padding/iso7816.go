@@ -0,0 +1,54 @@
+package padding
+
+import (
+	"crypto/subtle"
+)
+
+// iso7816Scheme pads with a 0x80 byte followed by zeros.
+type iso7816Scheme struct{}
+
+func (iso7816Scheme) String() string {
+	return "ISO/IEC 7816-4"
+}
+
+func (iso7816Scheme) Pad(message []byte, blockLen int) []byte {
+	padded := make([]byte, len(message)+padLength(len(message), blockLen))
+	copy(padded, message)
+	padded[len(message)] = 0x80
+
+	return padded
+}
+
+func (s iso7816Scheme) Unpad(padded []byte, blockLen int) ([]byte, error) {
+	for i := len(padded) - 1; i >= len(padded)-window(padded, blockLen); i-- {
+		switch padded[i] {
+		case 0x80:
+			return padded[:i], nil
+		case 0:
+		default:
+			return nil, InvalidPaddingError{s.String()}
+		}
+	}
+
+	return nil, InvalidPaddingError{s.String()}
+}
+
+func (s iso7816Scheme) unpadConstantTime(padded []byte, blockLen int) ([]byte, error) {
+	// Every byte that may be padding is read: done is set on the first
+	// non-zero byte from the end, which must be the marker.
+	start, done, good := 0, 0, 0
+	for i := len(padded) - 1; i >= len(padded)-window(padded, blockLen); i-- {
+		isMarker := subtle.ConstantTimeByteEq(padded[i], 0x80)
+		isZero := subtle.ConstantTimeByteEq(padded[i], 0)
+		found := (1 - done) & (1 - isZero)
+		start = subtle.ConstantTimeSelect(found, i, start)
+		good = subtle.ConstantTimeSelect(found, isMarker, good)
+		done |= found
+	}
+
+	if good != 1 {
+		return nil, InvalidPaddingError{s.String()}
+	}
+
+	return padded[:start], nil
+}
@@ -1,36 +1,117 @@
 package padding
 
+import (
+	"crypto/rand"
+	"crypto/subtle"
+)
+
 // PKCS7 adds padding to the given message according to the PKCS#7 spec.
 func PKCS7(message []byte, blockLen int) []byte {
-	paddedMsgLen := len(message)
-	if len(message)%blockLen == 0 {
-		paddedMsgLen += blockLen
+	return SchemePKCS7.Pad(message, blockLen)
+}
+
+// UnPKCS7 removes padding from the given message according to the PKCS#7 spec.
+// It returns an InvalidPaddingError if the message isn't correctly padded.
+func UnPKCS7(paddedMsg []byte, blockLen int) ([]byte, error) {
+	return SchemePKCS7.Unpad(paddedMsg, blockLen)
+}
+
+// lengthScheme is a padding scheme whose last byte is the padding length.
+// The other padding bytes depend on the scheme.
+type lengthScheme int
+
+const (
+	pkcs7 lengthScheme = iota
+	ansiX923
+	iso10126
+)
+
+func (s lengthScheme) String() string {
+	switch s {
+	case pkcs7:
+		return "PKCS#7"
+	case ansiX923:
+		return "ANSI X.923"
+	default:
+		return "ISO 10126"
+	}
+}
+
+// filler returns the value of the padding bytes before the length, and
+// whether it is checked when removing padding.
+func (s lengthScheme) filler(n int) (byte, bool) {
+	switch s {
+	case pkcs7:
+		return byte(n), true
+	case ansiX923:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func (s lengthScheme) Pad(message []byte, blockLen int) []byte {
+	n := padLength(len(message), blockLen)
+	padded := make([]byte, len(message)+n)
+	copy(padded, message)
+
+	pad := padded[len(message):]
+	if filler, checked := s.filler(n); checked {
+		for i := range pad {
+			pad[i] = filler
+		}
 	} else {
-		paddedMsgLen = paddedMsgLen + blockLen - (len(message) % blockLen)
+		rand.Read(pad)
 	}
 
-	paddedMsg := make([]byte, paddedMsgLen)
-	copy(paddedMsg, message)
+	pad[n-1] = byte(n)
+	return padded
+}
+
+func (s lengthScheme) Unpad(padded []byte, blockLen int) ([]byte, error) {
+	if len(padded) == 0 {
+		return nil, InvalidPaddingError{s.String()}
+	}
+
+	n := int(padded[len(padded)-1])
+	if n == 0 || n > blockLen || n > len(padded) {
+		return nil, InvalidPaddingError{s.String()}
+	}
 
-	paddingLen := paddedMsgLen - len(message)
-	for i := len(message); i < paddedMsgLen; i++ {
-		paddedMsg[i] = byte(paddingLen)
+	if filler, checked := s.filler(n); checked {
+		for i := 2; i <= n; i++ {
+			if padded[len(padded)-i] != filler {
+				return nil, InvalidPaddingError{s.String()}
+			}
+		}
 	}
 
-	return paddedMsg
+	return padded[:len(padded)-n], nil
 }
 
-// UnPKCS7 removes padding from the given message according to the PKCS#7 spec.
-func UnPKCS7(paddedMsg []byte, blockLen int) []byte {
-	paddingLen := paddedMsg[len(paddedMsg)-1]
-	for i := 1; i <= int(paddingLen); i++ {
-		if paddedMsg[len(paddedMsg)-i] != paddingLen {
-			panic(map[string]string{
-				"msg": string(paddedMsg),
-				"err": "invalid PKCS#7 padding",
-			})
+func (s lengthScheme) unpadConstantTime(padded []byte, blockLen int) ([]byte, error) {
+	if len(padded) == 0 {
+		return nil, InvalidPaddingError{s.String()}
+	}
+
+	n := int(padded[len(padded)-1])
+	good := subtle.ConstantTimeLessOrEq(1, n) &
+		subtle.ConstantTimeLessOrEq(n, blockLen) &
+		subtle.ConstantTimeLessOrEq(n, len(padded))
+
+	// Every byte that may be padding is read, and only counts if it is.
+	filler, checked := s.filler(n)
+	if checked {
+		for i := 2; i <= window(padded, blockLen); i++ {
+			isPadding := subtle.ConstantTimeLessOrEq(i, n)
+			matches := subtle.ConstantTimeByteEq(padded[len(padded)-i], filler)
+			good &= subtle.ConstantTimeSelect(isPadding, matches, 1)
 		}
 	}
 
-	return paddedMsg[:len(paddedMsg)-int(paddingLen)]
+	if good != 1 {
+		return nil, InvalidPaddingError{s.String()}
+	}
+
+	return padded[:len(padded)-n], nil
 }
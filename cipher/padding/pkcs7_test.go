@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
@@ -12,7 +13,8 @@ func TestPKCS7Padding(t *testing.T) {
 		padded := padding.PKCS7([]byte("YELLOW SUBMARINE"), 20)
 		assert.Equal(t, []byte("YELLOW SUBMARINE\x04\x04\x04\x04"), padded)
 
-		unpadded := padding.UnPKCS7(padded, 20)
+		unpadded, err := padding.UnPKCS7(padded, 20)
+		require.NoError(t, err)
 		assert.Equal(t, []byte("YELLOW SUBMARINE"), unpadded)
 	})
 
@@ -20,7 +22,8 @@ func TestPKCS7Padding(t *testing.T) {
 		padded := padding.PKCS7([]byte("YELLOW SUBMARINE"), 6)
 		assert.Equal(t, []byte("YELLOW SUBMARINE\x02\x02"), padded)
 
-		unpadded := padding.UnPKCS7(padded, 6)
+		unpadded, err := padding.UnPKCS7(padded, 6)
+		require.NoError(t, err)
 		assert.Equal(t, []byte("YELLOW SUBMARINE"), unpadded)
 	})
 
@@ -28,12 +31,24 @@ func TestPKCS7Padding(t *testing.T) {
 		padded := padding.PKCS7([]byte("YELLOW SUBMARINE"), 8)
 		assert.Equal(t, []byte("YELLOW SUBMARINE\x08\x08\x08\x08\x08\x08\x08\x08"), padded)
 
-		unpadded := padding.UnPKCS7(padded, 8)
+		unpadded, err := padding.UnPKCS7(padded, 8)
+		require.NoError(t, err)
 		assert.Equal(t, []byte("YELLOW SUBMARINE"), unpadded)
 	})
 
-	t.Run("panics on invalid padding", func(t *testing.T) {
-		assert.Panics(t, func() { padding.UnPKCS7([]byte("ICE ICE BABY\x05\x05\x05\x05"), 8) })
-		assert.Panics(t, func() { padding.UnPKCS7([]byte("ICE ICE BABY\x01\x02\x03\x04"), 8) })
+	t.Run("invalid padding", func(t *testing.T) {
+		invalid := []string{
+			"ICE ICE BABY\x05\x05\x05\x05",
+			"ICE ICE BABY\x01\x02\x03\x04",
+			"ICE ICE BABY\x00",
+			"ICE ICE BABY\x09\x09\x09\x09\x09\x09\x09\x09\x09",
+			"",
+		}
+
+		for _, msg := range invalid {
+			_, err := padding.UnPKCS7([]byte(msg), 8)
+			assert.ErrorIs(t, err, padding.ErrInvalidPadding, msg)
+			assert.Equal(t, padding.InvalidPaddingError{Scheme: "PKCS#7"}, err)
+		}
 	})
 }
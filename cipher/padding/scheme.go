@@ -0,0 +1,118 @@
+package padding
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidPadding is matched with errors.Is by every error returned
+	// when removing padding from a message that isn't correctly padded.
+	ErrInvalidPadding = errors.New("invalid padding")
+	// ErrNotConstantTime is returned when a scheme can't validate padding in
+	// constant time.
+	ErrNotConstantTime = errors.New("padding scheme can't validate in constant time")
+)
+
+// InvalidPaddingError is returned when removing padding from a message that
+// isn't correctly padded.
+// It doesn't tell why the padding is invalid, so that a scheme validating in
+// constant time doesn't leak it either.
+type InvalidPaddingError struct {
+	Scheme string
+}
+
+func (e InvalidPaddingError) Error() string {
+	return fmt.Sprintf("invalid %s padding", e.Scheme)
+}
+
+// Is makes InvalidPaddingError match ErrInvalidPadding.
+func (e InvalidPaddingError) Is(target error) bool {
+	return target == ErrInvalidPadding
+}
+
+// Scheme pads messages to a multiple of the block length and removes that
+// padding.
+type Scheme interface {
+	// Pad returns a padded copy of the message.
+	Pad(message []byte, blockLen int) []byte
+	// Unpad returns the message without its padding, or an
+	// InvalidPaddingError if it isn't correctly padded.
+	Unpad(padded []byte, blockLen int) ([]byte, error)
+	String() string
+}
+
+// Padding schemes.
+var (
+	// SchemePKCS7 pads with n bytes of value n.
+	SchemePKCS7 Scheme = lengthScheme(pkcs7)
+	// SchemeANSIX923 pads with zeros followed by the padding length.
+	SchemeANSIX923 Scheme = lengthScheme(ansiX923)
+	// SchemeISO10126 pads with random bytes followed by the padding length.
+	SchemeISO10126 Scheme = lengthScheme(iso10126)
+	// SchemeISO7816 pads with 0x80 followed by zeros, as ISO/IEC 7816-4.
+	SchemeISO7816 Scheme = iso7816Scheme{}
+	// SchemeZero pads with zeros, without adding a block when the message is
+	// already aligned.
+	// Empty messages are padded to a block of zeros, so that they still
+	// encrypt to a ciphertext.
+	// Unpad never fails, but it can't tell padding from zeros at the end of
+	// the message: it only suits messages that don't end with zeros.
+	SchemeZero Scheme = zeroScheme{}
+)
+
+// constantTimeScheme is implemented by schemes that can validate padding in
+// constant time.
+type constantTimeScheme interface {
+	Scheme
+	unpadConstantTime(padded []byte, blockLen int) ([]byte, error)
+}
+
+// ConstantTime returns the scheme with an Unpad that validates padding in
+// constant time: its running time only depends on the length of the padded
+// message, not on its content.
+// Unpad otherwise fails on the first invalid byte, which can leak through
+// timing which byte was wrong to a padding oracle attacker.
+// It returns ErrNotConstantTime if the scheme isn't one of this package's
+// schemes.
+func ConstantTime(s Scheme) (Scheme, error) {
+	switch s := s.(type) {
+	case constantTime:
+		return s, nil
+	case constantTimeScheme:
+		return constantTime{s}, nil
+	default:
+		return nil, ErrNotConstantTime
+	}
+}
+
+type constantTime struct {
+	s constantTimeScheme
+}
+
+func (c constantTime) Pad(message []byte, blockLen int) []byte {
+	return c.s.Pad(message, blockLen)
+}
+
+func (c constantTime) Unpad(padded []byte, blockLen int) ([]byte, error) {
+	return c.s.unpadConstantTime(padded, blockLen)
+}
+
+func (c constantTime) String() string {
+	return c.s.String()
+}
+
+// padLength returns the number of padding bytes needed to reach a multiple of
+// the block length, between 1 and blockLen.
+func padLength(messageLen, blockLen int) int {
+	return blockLen - messageLen%blockLen
+}
+
+// window returns the number of trailing bytes that may contain padding.
+func window(padded []byte, blockLen int) int {
+	if len(padded) < blockLen {
+		return len(padded)
+	}
+
+	return blockLen
+}
@@ -0,0 +1,127 @@
+package padding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/padding"
+)
+
+func TestSchemes(t *testing.T) {
+	testCases := []struct {
+		scheme  padding.Scheme
+		padded  string
+		invalid []string
+	}{{
+		padding.SchemePKCS7,
+		"ICE ICE BABY\x04\x04\x04\x04",
+		[]string{
+			"ICE ICE BABY\x04\x04\x03\x04",
+			"ICE ICE BABY\x00",
+			"ICE ICE BABY\x11",
+			"\x02",
+			"",
+		},
+	}, {
+		padding.SchemeANSIX923,
+		"ICE ICE BABY\x00\x00\x00\x04",
+		[]string{
+			"ICE ICE BABY\x00\x01\x00\x04",
+			"ICE ICE BABY\x00",
+			"ICE ICE BABY\x11",
+			"",
+		},
+	}, {
+		padding.SchemeISO7816,
+		"ICE ICE BABY\x80\x00\x00\x00",
+		[]string{
+			"ICE ICE BABY\x80\x00\x01\x00",
+			"ICE ICE BABY\x00\x00\x00\x00",
+			"ICE ICE BABY\x81",
+			"\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+			"",
+		},
+	}, {
+		padding.SchemeZero,
+		"ICE ICE BABY\x00\x00\x00\x00",
+		nil,
+	}}
+
+	for _, tc := range testCases {
+		for _, s := range []padding.Scheme{tc.scheme, constantTime(t, tc.scheme)} {
+			t.Run(s.String(), func(t *testing.T) {
+				padded := s.Pad([]byte("ICE ICE BABY"), 16)
+				assert.Equal(t, tc.padded, string(padded))
+
+				unpadded, err := s.Unpad(padded, 16)
+				require.NoError(t, err)
+				assert.Equal(t, "ICE ICE BABY", string(unpadded))
+
+				for _, msg := range tc.invalid {
+					_, err := s.Unpad([]byte(msg), 16)
+					assert.ErrorIs(t, err, padding.ErrInvalidPadding, msg)
+					assert.Equal(t, padding.InvalidPaddingError{Scheme: tc.scheme.String()}, err)
+				}
+			})
+		}
+	}
+
+	t.Run("ISO 10126", func(t *testing.T) {
+		for _, s := range []padding.Scheme{padding.SchemeISO10126, constantTime(t, padding.SchemeISO10126)} {
+			padded := s.Pad([]byte("ICE ICE BABY"), 16)
+			assert.Len(t, padded, 16)
+			assert.Equal(t, byte(4), padded[15])
+
+			unpadded, err := s.Unpad([]byte("ICE ICE BABY\x42\x13\x37\x04"), 16)
+			require.NoError(t, err)
+			assert.Equal(t, "ICE ICE BABY", string(unpadded))
+
+			_, err = s.Unpad([]byte("ICE ICE BABY\x42\x13\x37\x11"), 16)
+			assert.ErrorIs(t, err, padding.ErrInvalidPadding)
+		}
+	})
+
+	t.Run("full padding block", func(t *testing.T) {
+		message := []byte("YELLOW SUBMARINE")
+		for _, s := range []padding.Scheme{padding.SchemePKCS7, padding.SchemeANSIX923, padding.SchemeISO10126, padding.SchemeISO7816} {
+			padded := s.Pad(message, 16)
+			assert.Len(t, padded, 32, s.String())
+
+			unpadded, err := constantTime(t, s).Unpad(padded, 16)
+			require.NoError(t, err)
+			assert.Equal(t, message, unpadded)
+		}
+
+		assert.Equal(t, message, padding.SchemeZero.Pad(message, 16))
+	})
+
+	t.Run("empty message", func(t *testing.T) {
+		for _, s := range []padding.Scheme{padding.SchemePKCS7, padding.SchemeANSIX923, padding.SchemeISO10126, padding.SchemeISO7816, padding.SchemeZero} {
+			padded := s.Pad(nil, 16)
+			assert.Len(t, padded, 16, s.String())
+
+			unpadded, err := s.Unpad(padded, 16)
+			require.NoError(t, err)
+			assert.Empty(t, unpadded)
+		}
+	})
+}
+
+type customScheme struct {
+	padding.Scheme
+}
+
+func constantTime(t *testing.T, s padding.Scheme) padding.Scheme {
+	ct, err := padding.ConstantTime(s)
+	require.NoError(t, err)
+	return ct
+}
+
+func TestConstantTime(t *testing.T) {
+	s := constantTime(t, padding.SchemePKCS7)
+	assert.Equal(t, s, constantTime(t, s))
+
+	_, err := padding.ConstantTime(customScheme{padding.SchemePKCS7})
+	assert.Equal(t, padding.ErrNotConstantTime, err)
+}
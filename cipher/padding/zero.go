@@ -0,0 +1,43 @@
+package padding
+
+import (
+	"crypto/subtle"
+)
+
+// zeroScheme pads with zeros.
+type zeroScheme struct{}
+
+func (zeroScheme) String() string {
+	return "zero"
+}
+
+func (zeroScheme) Pad(message []byte, blockLen int) []byte {
+	n := padLength(len(message), blockLen) % blockLen
+	if len(message) == 0 {
+		n = blockLen
+	}
+
+	padded := make([]byte, len(message)+n)
+	copy(padded, message)
+
+	return padded
+}
+
+func (zeroScheme) Unpad(padded []byte, blockLen int) ([]byte, error) {
+	end := len(padded)
+	for end > len(padded)-window(padded, blockLen) && padded[end-1] == 0 {
+		end--
+	}
+
+	return padded[:end], nil
+}
+
+func (zeroScheme) unpadConstantTime(padded []byte, blockLen int) ([]byte, error) {
+	end, done := len(padded), 0
+	for i := len(padded) - 1; i >= len(padded)-window(padded, blockLen); i-- {
+		done |= 1 - subtle.ConstantTimeByteEq(padded[i], 0)
+		end = subtle.ConstantTimeSelect(done, end, i)
+	}
+
+	return padded[:end], nil
+}
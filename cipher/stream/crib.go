@@ -0,0 +1,225 @@
+package stream
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/t-bast/cryptopals/score"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+// ErrOutOfRange is returned when pinning bytes outside of the ciphertexts.
+var ErrOutOfRange = errors.New("position out of range")
+
+// ManyTimePad holds ciphertexts that were encrypted with the same keystream
+// (for example CTR with a reused nonce) and the keystream bytes known so far.
+// Keystream bytes are found by dragging cribs (guessed plaintext) across the
+// ciphertexts and pinning the ones that make sense; the remaining bytes can
+// then be recovered statistically with Solve.
+type ManyTimePad struct {
+	ciphertexts [][]byte
+	keystream   []byte
+	pinned      []bool
+}
+
+// NewManyTimePad creates a many-time pad from ciphertexts that share a
+// keystream.
+func NewManyTimePad(ciphertexts [][]byte) *ManyTimePad {
+	maxLength := 0
+	for _, ciphertext := range ciphertexts {
+		if len(ciphertext) > maxLength {
+			maxLength = len(ciphertext)
+		}
+	}
+
+	return &ManyTimePad{
+		ciphertexts: ciphertexts,
+		keystream:   make([]byte, maxLength),
+		pinned:      make([]bool, maxLength),
+	}
+}
+
+// Len returns the length of the longest ciphertext.
+func (p *ManyTimePad) Len() int {
+	return len(p.keystream)
+}
+
+// CribMatch is the result of placing a crib in one of the ciphertexts at a
+// given offset.
+type CribMatch struct {
+	// Ciphertext is the index of the ciphertext the crib was placed in.
+	Ciphertext int
+	Offset     int
+	// Keystream implied by the crib at that offset.
+	Keystream []byte
+	// Plaintexts implied by the crib in every ciphertext at that offset.
+	// They are shorter than the crib for ciphertexts that end before it.
+	Plaintexts [][]byte
+	// Score of the plaintexts implied in the other ciphertexts, or -Inf if
+	// none of them reach the offset.
+	Score float64
+}
+
+// Drag slides the crib across every position of every ciphertext and returns
+// the plaintexts it implies in the other ciphertexts, best first according to
+// the scorer.
+// Positions where the crib would reach past the end of its ciphertext, or
+// where no other ciphertext reaches, are skipped.
+func (p *ManyTimePad) Drag(crib []byte, s score.Scorer) []CribMatch {
+	var matches []CribMatch
+	for i, ciphertext := range p.ciphertexts {
+		for offset := 0; offset+len(crib) <= len(ciphertext); offset++ {
+			if m, _ := p.Place(crib, i, offset, s); !math.IsInf(m.Score, -1) {
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches
+}
+
+// Place puts the crib in the given ciphertext at the given offset and returns
+// the plaintexts it implies in every ciphertext.
+// It returns ErrOutOfRange if the crib doesn't fit in the ciphertext.
+func (p *ManyTimePad) Place(crib []byte, ciphertext, offset int, s score.Scorer) (CribMatch, error) {
+	if ciphertext < 0 || ciphertext >= len(p.ciphertexts) {
+		return CribMatch{}, ErrOutOfRange
+	}
+
+	if offset < 0 || offset+len(crib) > len(p.ciphertexts[ciphertext]) {
+		return CribMatch{}, ErrOutOfRange
+	}
+
+	keystream := xor.Bytes(crib, p.ciphertexts[ciphertext][offset:])
+
+	var others []byte
+	plaintexts := make([][]byte, len(p.ciphertexts))
+	for i, c := range p.ciphertexts {
+		plaintexts[i] = xorAt(c, keystream, offset)
+		if i != ciphertext && len(plaintexts[i]) > 0 {
+			others = append(others, plaintexts[i]...)
+			others = append(others, '\n')
+		}
+	}
+
+	m := CribMatch{
+		Ciphertext: ciphertext,
+		Offset:     offset,
+		Keystream:  keystream,
+		Plaintexts: plaintexts,
+		Score:      math.Inf(-1),
+	}
+
+	if len(others) > 0 {
+		m.Score = s.Score(others)
+	}
+
+	return m, nil
+}
+
+// Pin sets known keystream bytes starting at the given offset.
+func (p *ManyTimePad) Pin(offset int, keystream []byte) error {
+	if offset < 0 || offset+len(keystream) > len(p.keystream) {
+		return ErrOutOfRange
+	}
+
+	copy(p.keystream[offset:], keystream)
+	for i := range keystream {
+		p.pinned[offset+i] = true
+	}
+
+	return nil
+}
+
+// PinPlaintext pins the keystream bytes implied by a known plaintext in the
+// given ciphertext at the given offset.
+func (p *ManyTimePad) PinPlaintext(ciphertext, offset int, plaintext []byte) error {
+	if ciphertext < 0 || ciphertext >= len(p.ciphertexts) {
+		return ErrOutOfRange
+	}
+
+	c := p.ciphertexts[ciphertext]
+	if offset < 0 || offset+len(plaintext) > len(c) {
+		return ErrOutOfRange
+	}
+
+	return p.Pin(offset, xor.Bytes(plaintext, c[offset:]))
+}
+
+// Unpin forgets n known keystream bytes starting at the given offset.
+func (p *ManyTimePad) Unpin(offset, n int) error {
+	if offset < 0 || n < 0 || offset+n > len(p.keystream) {
+		return ErrOutOfRange
+	}
+
+	for i := offset; i < offset+n; i++ {
+		p.keystream[i] = 0
+		p.pinned[i] = false
+	}
+
+	return nil
+}
+
+// Pinned returns whether the keystream byte at the given offset is known.
+// Offsets past the keystream are never known.
+func (p *ManyTimePad) Pinned(offset int) bool {
+	if offset < 0 || offset >= len(p.pinned) {
+		return false
+	}
+
+	return p.pinned[offset]
+}
+
+// Solve returns the full keystream: pinned bytes are kept and the others are
+// recovered statistically column by column like PwnCTRNonceReuse, using the
+// scorer.
+func (p *ManyTimePad) Solve(s score.Scorer) []byte {
+	keystream := make([]byte, len(p.keystream))
+	for i := range keystream {
+		if p.pinned[i] {
+			keystream[i] = p.keystream[i]
+			continue
+		}
+
+		var column []byte
+		for _, ciphertext := range p.ciphertexts {
+			if i < len(ciphertext) {
+				column = append(column, ciphertext[i])
+			}
+		}
+
+		keystream[i], _, _ = xor.BreakSingleByte(column, s)
+	}
+
+	return keystream
+}
+
+// Plaintexts decrypts every ciphertext with the given keystream.
+func (p *ManyTimePad) Plaintexts(keystream []byte) [][]byte {
+	plaintexts := make([][]byte, len(p.ciphertexts))
+	for i, ciphertext := range p.ciphertexts {
+		plaintexts[i] = xorAt(ciphertext, keystream, 0)
+	}
+
+	return plaintexts
+}
+
+// xorAt XORs the keystream with the ciphertext starting at the given offset,
+// stopping at the end of the shortest one.
+func xorAt(ciphertext, keystream []byte, offset int) []byte {
+	if offset >= len(ciphertext) {
+		return nil
+	}
+
+	c := ciphertext[offset:]
+	if len(c) > len(keystream) {
+		c = c[:len(keystream)]
+	}
+
+	return xor.Bytes(c, keystream)
+}
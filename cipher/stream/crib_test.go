@@ -0,0 +1,94 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func TestManyTimePad(t *testing.T) {
+	lines := []string{
+		"I have met them at close of day",
+		"Coming with vivid faces",
+		"From counter or desk among grey",
+		"Eighteenth-century houses.",
+		"I have passed with a nod of the head",
+		"Or polite meaningless words,",
+		"Or have lingered awhile and said",
+		"Polite meaningless words,",
+		"And thought before I had done",
+		"Of a mocking tale or a gibe",
+		"To please a companion",
+		"Around the fire at the club,",
+	}
+
+	enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 0)
+	var ciphertexts [][]byte
+	for _, line := range lines {
+		ciphertexts = append(ciphertexts, enc.Encrypt([]byte(line)))
+	}
+
+	p := stream.NewManyTimePad(ciphertexts)
+	require.Equal(t, 36, p.Len())
+
+	t.Run("drag", func(t *testing.T) {
+		crib := "meaningless words"
+		matches := p.Drag([]byte(crib), score.English())
+		require.NotEmpty(t, matches)
+
+		// The crib appears in two lines: either of them reveals the others.
+		best := matches[0]
+		assert.Contains(t, []int{5, 7}, best.Ciphertext)
+		for i, line := range lines {
+			expected := ""
+			if best.Offset < len(line) {
+				expected = line[best.Offset:]
+				if len(expected) > len(crib) {
+					expected = expected[:len(crib)]
+				}
+			}
+
+			assert.Equal(t, expected, string(best.Plaintexts[i]))
+		}
+	})
+
+	t.Run("place out of range", func(t *testing.T) {
+		_, err := p.Place([]byte("too long"), 10, 15, score.English())
+		assert.Equal(t, stream.ErrOutOfRange, err)
+		_, err = p.Place([]byte("crib"), len(lines), 0, score.English())
+		assert.Equal(t, stream.ErrOutOfRange, err)
+		_, err = p.Place([]byte("crib"), 0, -1, score.English())
+		assert.Equal(t, stream.ErrOutOfRange, err)
+
+		m, err := p.Place([]byte("I have"), 0, 0, score.English())
+		require.NoError(t, err)
+		assert.Equal(t, "I have", string(m.Plaintexts[4]))
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		assert.Equal(t, stream.ErrOutOfRange, p.PinPlaintext(10, 20, []byte("too long")))
+		assert.Equal(t, stream.ErrOutOfRange, p.Pin(30, make([]byte, 10)))
+
+		// Few lines cover the last bytes: statistics can't recover them, so we
+		// pin them from the end of the longest line.
+		require.NoError(t, p.PinPlaintext(4, 23, []byte(lines[4][23:])))
+		assert.False(t, p.Pinned(22))
+		assert.True(t, p.Pinned(35))
+		assert.False(t, p.Pinned(36))
+		assert.False(t, p.Pinned(-1))
+
+		// A few columns at the beginning are wrong too: a crib fixes them.
+		require.NoError(t, p.PinPlaintext(11, 0, []byte("Around the")))
+
+		plaintexts := p.Plaintexts(p.Solve(score.LetterFrequencyScorer))
+		for i, line := range lines {
+			assert.Equal(t, line, string(plaintexts[i]))
+		}
+
+		require.NoError(t, p.Unpin(0, 36))
+		assert.False(t, p.Pinned(35))
+	})
+}
@@ -2,53 +2,126 @@ package stream
 
 import (
 	"crypto/aes"
-	"encoding/base64"
+	"crypto/cipher"
 	"encoding/binary"
+	"errors"
+	"io"
+	"math"
 
+	"github.com/t-bast/cryptopals/internal/parallel"
 	"github.com/t-bast/cryptopals/score"
 	"github.com/t-bast/cryptopals/xor"
 )
 
+// minParallelBlocks is the minimum number of blocks each goroutine processes
+// when CTR runs in parallel: below that, goroutines cost more than they save.
+const minParallelBlocks = 1024
+
+// Errors returned when configuring CTR.
+var (
+	ErrInvalidLayout = errors.New("invalid counter layout")
+	ErrInvalidNonce  = errors.New("invalid nonce length")
+)
+
+// CounterLayout describes how the counter block is built from the nonce and
+// the block counter: the nonce comes first and the counter fills the rest of
+// the block.
+// The counter wraps around when it overflows its bytes, without carrying
+// into the nonce.
+type CounterLayout struct {
+	// NonceSize is the number of nonce bytes at the start of the block.
+	// The counter takes the remaining 1 to 8 bytes.
+	NonceSize int
+	// BigEndian encodes the counter in big-endian byte order instead of
+	// little-endian.
+	BigEndian bool
+}
+
+// Counter layouts commonly found in protocols.
+var (
+	// LayoutLittleEndian64 is the cryptopals layout: an 8-byte nonce and a
+	// 64-bit little-endian counter.
+	LayoutLittleEndian64 = CounterLayout{NonceSize: 8}
+	// LayoutBigEndian64 is an 8-byte nonce and a 64-bit big-endian counter.
+	LayoutBigEndian64 = CounterLayout{NonceSize: 8, BigEndian: true}
+	// LayoutBigEndian32 is a 12-byte nonce and a 32-bit big-endian counter,
+	// like in GCM and RFC 3686.
+	LayoutBigEndian32 = CounterLayout{NonceSize: 12, BigEndian: true}
+)
+
+// counterSize returns the number of counter bytes, or 0 if the layout is
+// invalid.
+func (l CounterLayout) counterSize() int {
+	size := aes.BlockSize - l.NonceSize
+	if l.NonceSize < 0 || size < 1 || 8 < size {
+		return 0
+	}
+
+	return size
+}
+
 // CTR implements CTR encryption using AES.
+// It is a cipher.Stream: XORKeyStream starts at the beginning of the
+// keystream and carries on from where the previous call stopped, while
+// Encrypt, Decrypt and XORKeyStreamAt don't depend on previous calls.
 type CTR struct {
-	key   []byte
-	nonce []byte
+	b cipher.Block
+	// err is set when the key is invalid: it is only returned when the CTR
+	// is used, because NewCTR can't fail.
+	err    error
+	nonce  []byte
+	layout CounterLayout
+	start  uint64
+	// offset in the keystream of the next XORKeyStream call.
+	offset uint64
 }
 
-// NewCTR creates a new CTR encryptor with the given key and nonce.
+// NewCTR creates a new CTR encryptor with the given key and nonce, using
+// LayoutLittleEndian64 with a counter starting at 0.
 func NewCTR(key []byte, nonce uint64) *CTR {
 	nonceBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(nonceBytes, nonce)
 
+	b, err := aes.NewCipher(key)
+
 	return &CTR{
-		key:   key,
-		nonce: nonceBytes,
+		b:      b,
+		err:    err,
+		nonce:  nonceBytes,
+		layout: LayoutLittleEndian64,
 	}
 }
 
-// Encrypt a message in CTR mode.
-func (e *CTR) Encrypt(message []byte) []byte {
-	b, err := aes.NewCipher(e.key)
-	if err != nil {
-		panic(err)
+// NewCTRWithLayout creates a new CTR encryptor with the given key, nonce and
+// counter layout, with a counter starting at start.
+// The nonce must be exactly layout.NonceSize bytes long.
+func NewCTRWithLayout(key, nonce []byte, layout CounterLayout, start uint64) (*CTR, error) {
+	if layout.counterSize() == 0 {
+		return nil, ErrInvalidLayout
 	}
 
-	blockCount := len(message) / 16
-	if len(message)%16 != 0 {
-		blockCount++
+	if len(nonce) != layout.NonceSize {
+		return nil, ErrInvalidNonce
 	}
 
-	keystream := make([]byte, 16*blockCount)
-	for i := uint64(0); i < uint64(blockCount); i++ {
-		counterBytes := make([]byte, 8)
-		binary.LittleEndian.PutUint64(counterBytes, i)
-
-		start := i * 16
-		end := start + 16
-		b.Encrypt(keystream[start:end], append(e.nonce, counterBytes...))
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
 	}
 
-	return xor.Bytes(message, keystream[:len(message)])
+	return &CTR{
+		b:      b,
+		nonce:  append([]byte(nil), nonce...),
+		layout: layout,
+		start:  start,
+	}, nil
+}
+
+// Encrypt a message in CTR mode.
+func (e *CTR) Encrypt(message []byte) []byte {
+	encrypted := make([]byte, len(message))
+	e.XORKeyStreamAt(encrypted, message, 0)
+	return encrypted
 }
 
 // Decrypt a message in CTR mode.
@@ -56,9 +129,119 @@ func (e *CTR) Decrypt(ciphertext []byte) []byte {
 	return e.Encrypt(ciphertext)
 }
 
+// XORKeyStream XORs each byte of src with the next keystream byte and writes
+// the result to dst.
+func (e *CTR) XORKeyStream(dst, src []byte) {
+	e.XORKeyStreamAt(dst, src, e.offset)
+	e.offset += uint64(len(src))
+}
+
+// XORKeyStreamAt XORs src with the keystream starting at the given byte
+// offset and writes the result to dst.
+// Only the keystream blocks covering src are generated, which gives random
+// access to the ciphertext.
+// Large inputs are processed in parallel.
+func (e *CTR) XORKeyStreamAt(dst, src []byte, offset uint64) {
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+
+	b := e.block()
+	blocks := (len(src) + aes.BlockSize - 1) / aes.BlockSize
+	bounds := parallel.Split(blocks, minParallelBlocks)
+	parallel.Run(bounds, func(_, first, last int) {
+		start := first * aes.BlockSize
+		end := last * aes.BlockSize
+		if end > len(src) {
+			end = len(src)
+		}
+
+		e.xorKeyStreamAt(b, dst[start:end], src[start:end], offset+uint64(start))
+	})
+}
+
+func (e *CTR) xorKeyStreamAt(b cipher.Block, dst, src []byte, offset uint64) {
+	var counterBlock, keystream [aes.BlockSize]byte
+	counter := offset / aes.BlockSize
+	used := int(offset % aes.BlockSize)
+
+	for i := 0; i < len(src); {
+		e.counterBlock(counterBlock[:], counter)
+		b.Encrypt(keystream[:], counterBlock[:])
+		for ; used < aes.BlockSize && i < len(src); used++ {
+			dst[i] = src[i] ^ keystream[used]
+			i++
+		}
+
+		counter++
+		used = 0
+	}
+}
+
+// Stream returns the keystream as a cipher.Stream, starting from its first
+// byte.
+// It can be used with xor.NewReader and xor.NewWriter to encrypt data
+// without loading it whole.
+func (e *CTR) Stream() cipher.Stream {
+	b := e.block()
+	counterBlock := make([]byte, aes.BlockSize)
+	counter := uint64(0)
+
+	return newKeystream(aes.BlockSize, func(block []byte) {
+		e.counterBlock(counterBlock, counter)
+		b.Encrypt(block, counterBlock)
+		counter++
+	})
+}
+
+// block returns the cached block cipher, and panics if the key was invalid.
+func (e *CTR) block() cipher.Block {
+	if e.err != nil {
+		panic(e.err)
+	}
+
+	return e.b
+}
+
+// counterBlock writes the nonce and the i-th counter value to dst.
+func (e *CTR) counterBlock(dst []byte, i uint64) {
+	copy(dst, e.nonce)
+
+	counter := e.start + i
+	c := dst[e.layout.NonceSize:]
+	for j := range c {
+		if e.layout.BigEndian {
+			c[len(c)-1-j] = byte(counter)
+		} else {
+			c[j] = byte(counter)
+		}
+
+		counter >>= 8
+	}
+}
+
 // PwnCTRNonceReuseLetterFrequency takes multiples ciphertexts generated with
 // the same nonce and figures out the keystream from letter frequency.
+// Keystream bytes that would produce characters outside of the 32..122 range
+// are rejected: use BreakNonceReuse for plaintexts that contain other bytes.
 func PwnCTRNonceReuseLetterFrequency(ciphertexts [][]byte) []byte {
+	return PwnCTRNonceReuse(ciphertexts, score.ScorerFunc(func(column []byte) float64 {
+		for _, plain := range column {
+			if plain < 32 || 122 < plain {
+				return 0
+			}
+		}
+
+		return float64(score.LetterFrequency(column))
+	}))
+}
+
+// PwnCTRNonceReuse takes multiples ciphertexts generated with the same nonce
+// and figures out the keystream one byte at a time.
+// Each keystream byte is chosen to maximize the score of the characters it
+// decrypts at that position in every ciphertext, so the scorer should work on
+// isolated characters rather than on words.
+func PwnCTRNonceReuse(ciphertexts [][]byte, s score.Scorer) []byte {
 	maxLength := 0
 	for _, ciphertext := range ciphertexts {
 		if len(ciphertext) > maxLength {
@@ -66,48 +249,58 @@ func PwnCTRNonceReuseLetterFrequency(ciphertexts [][]byte) []byte {
 		}
 	}
 
-	var keystream []byte
+	keystream := make([]byte, maxLength)
 	for i := 0; i < maxLength; i++ {
-		bestCandidate := byte(0)
-		bestScore := float32(0)
-
-		for j := 0; j < 256; j++ {
-			currentScore := float32(0.0)
-			for _, ciphertext := range ciphertexts {
-				if len(ciphertext) < i+1 {
-					continue
-				}
-
-				plain := ciphertext[i] ^ byte(j)
-				if plain < 32 || 122 < plain {
-					currentScore = 0
-					break
-				} else {
-					currentScore += score.LetterFrequency([]byte{plain})
-				}
-			}
-
-			if currentScore > bestScore {
-				bestCandidate = byte(j)
-				bestScore = currentScore
+		var column []byte
+		for _, ciphertext := range ciphertexts {
+			if i < len(ciphertext) {
+				column = append(column, ciphertext[i])
 			}
 		}
 
-		keystream = append(keystream, bestCandidate)
+		keystream[i], _, _ = xor.BreakSingleByte(column, s)
 	}
 
 	return keystream
 }
 
+// PwnCTRNonceReuseLanguages breaks nonce reuse like PwnCTRNonceReuse once for
+// each language model, using its unigram view.
+// It returns the keystream whose plaintexts are the most likely under their
+// model, and that model.
+func PwnCTRNonceReuseLanguages(ciphertexts [][]byte, models ...*score.Model) ([]byte, *score.Model) {
+	var best []byte
+	var bestModel *score.Model
+	bestScore := math.Inf(-1)
+
+	for _, m := range models {
+		keystream := PwnCTRNonceReuse(ciphertexts, m.WithOrder(1))
+
+		var plaintexts []byte
+		for _, ciphertext := range ciphertexts {
+			plaintexts = append(plaintexts, xor.Bytes(ciphertext, keystream)...)
+			plaintexts = append(plaintexts, '\n')
+		}
+
+		if s := m.Score(plaintexts); s > bestScore {
+			best = keystream
+			bestModel = m
+			bestScore = s
+		}
+	}
+
+	return best, bestModel
+}
+
 // PwnCTRNonceReuseStatistical interprets nonce reuse as a repeated-key XOR
 // and uses it to find the key stream.
-func PwnCTRNonceReuseStatistical(ciphertexts [][]byte) []byte {
+// The scorer is used to break the repeated-key XOR; if nil, letter frequency
+// is used.
+func PwnCTRNonceReuseStatistical(ciphertexts [][]byte, s score.Scorer) []byte {
 	minLength := len(ciphertexts[0])
-	minCipherIndex := 0
-	for i, ciphertext := range ciphertexts {
+	for _, ciphertext := range ciphertexts {
 		if len(ciphertext) < minLength {
 			minLength = len(ciphertext)
-			minCipherIndex = i
 		}
 	}
 
@@ -116,23 +309,32 @@ func PwnCTRNonceReuseStatistical(ciphertexts [][]byte) []byte {
 		fullCiphertext = append(fullCiphertext, ciphertext[:minLength]...)
 	}
 
-	decrypted := []byte(xor.DecryptWithRepeat(base64.StdEncoding.EncodeToString(fullCiphertext)))
-	keystream := xor.Bytes(
-		decrypted[minLength*minCipherIndex:minLength*(minCipherIndex+1)],
-		fullCiphertext[minLength*minCipherIndex:minLength*(minCipherIndex+1)])
+	// We know the key size: it's the length of the truncated ciphertexts.
+	keystream, _, err := xor.BreakRepeatingXOR(fullCiphertext, xor.Options{
+		MinKeySize: minLength,
+		MaxKeySize: minLength,
+		Scorer:     s,
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return keystream
 }
 
-// EditCTR allows the user to seek into the ciphertext, decrypt and re-encrypt
-// with a different plaintext.
+// EditCTR allows the user to seek into the ciphertext and re-encrypt it with
+// a different plaintext.
+// Only the edited range is re-encrypted, and the new plaintext is truncated
+// at the end of the ciphertext.
 // This feature opens a security hole in AES CTR.
 func EditCTR(ciphertext []byte, key []byte, offset int, newPlaintext []byte) []byte {
 	enc := NewCTR(key, 0)
 
-	decrypted := enc.Decrypt(ciphertext)
-	copy(decrypted[offset:], newPlaintext)
+	edited := make([]byte, len(ciphertext))
+	copy(edited, ciphertext)
 
-	encrypted := enc.Encrypt(decrypted)
-	return encrypted
+	n := copy(edited[offset:], newPlaintext)
+	enc.XORKeyStreamAt(edited[offset:offset+n], newPlaintext[:n], uint64(offset))
+
+	return edited
 }
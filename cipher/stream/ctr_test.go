@@ -1,10 +1,21 @@
 package stream_test
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/internal/paralleltest"
+	"github.com/t-bast/cryptopals/score"
+	"github.com/t-bast/cryptopals/xor"
 )
 
 func TestEditCTR(t *testing.T) {
@@ -16,4 +27,191 @@ func TestEditCTR(t *testing.T) {
 
 	decrypted := enc.Decrypt(ciphertext2)
 	assert.Equal(t, []byte("It will never be the beauties that spongebob show, Those damaged products of a good-for-nothing age, Their feet shod with high shoes, hands holding castanets, Who can ever satisfy any heart like mine."), decrypted)
+
+	// The rest of the ciphertext is left untouched, and the new plaintext is
+	// truncated at its end.
+	assert.Equal(t, ciphertext[:35], ciphertext2[:35])
+	assert.Equal(t, ciphertext[44:], ciphertext2[44:])
+
+	ciphertext3 := stream.EditCTR(ciphertext, []byte(key), len(ciphertext)-3, []byte("wine."))
+	assert.Equal(t, len(ciphertext), len(ciphertext3))
+	assert.Equal(t, "win", string(enc.Decrypt(ciphertext3)[len(ciphertext)-3:]))
+}
+
+func TestCTRStream(t *testing.T) {
+	enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 42)
+	message := []byte("Ce ne seront jamais ces beautes de vignettes, Produits avaries, nes d'un siecle vaurien")
+
+	var encrypted bytes.Buffer
+	w := xor.NewWriter(&encrypted, enc.Stream())
+	_, err := w.Write(message[:20])
+	require.NoError(t, err)
+	_, err = w.Write(message[20:])
+	require.NoError(t, err)
+
+	assert.Equal(t, enc.Encrypt(message), encrypted.Bytes())
+}
+
+func TestCTRLayout(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	message := []byte("Ce ne seront jamais ces beautes de vignettes, Produits avaries, nes d'un siecle vaurien")
+
+	t.Run("RFC 3686", func(t *testing.T) {
+		key, _ := hex.DecodeString("7e24067817fae0d743d6ce1f32539163")
+		nonce, _ := hex.DecodeString("006cb6dbc0543b59da48d90b")
+		plaintext, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+		enc, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutBigEndian32, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, "5104a106168a72d9790d41ee8edad388eb2e1efc46da57c8fce630df9141be28", hex.EncodeToString(enc.Encrypt(plaintext)))
+	})
+
+	t.Run("big-endian 64-bit", func(t *testing.T) {
+		nonce := []byte("12345678")
+		enc, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutBigEndian64, 42)
+		require.NoError(t, err)
+
+		b, _ := aes.NewCipher(key)
+		iv := append([]byte("12345678"), 0, 0, 0, 0, 0, 0, 0, 42)
+		expected := make([]byte, len(message))
+		cipher.NewCTR(b, iv).XORKeyStream(expected, message)
+
+		assert.Equal(t, expected, enc.Encrypt(message))
+	})
+
+	t.Run("little-endian 64-bit", func(t *testing.T) {
+		nonce := []byte{42, 0, 0, 0, 0, 0, 0, 0}
+		enc, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutLittleEndian64, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, stream.NewCTR(key, 42).Encrypt(message), enc.Encrypt(message))
+	})
+
+	t.Run("counter wraps around", func(t *testing.T) {
+		nonce := make([]byte, 12)
+		enc, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutBigEndian32, 0xffffffff)
+		require.NoError(t, err)
+		restarted, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutBigEndian32, 0)
+		require.NoError(t, err)
+
+		zeros := make([]byte, 32)
+		assert.Equal(t, restarted.Encrypt(zeros[:16]), enc.Encrypt(zeros)[16:])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := stream.NewCTRWithLayout(key, make([]byte, 8), stream.LayoutBigEndian32, 0)
+		assert.Equal(t, stream.ErrInvalidNonce, err)
+
+		_, err = stream.NewCTRWithLayout(key, make([]byte, 4), stream.CounterLayout{NonceSize: 4}, 0)
+		assert.Equal(t, stream.ErrInvalidLayout, err)
+
+		_, err = stream.NewCTRWithLayout(key[:5], make([]byte, 8), stream.LayoutBigEndian64, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestCTRXORKeyStream(t *testing.T) {
+	enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 42)
+	message := []byte("Ce ne seront jamais ces beautes de vignettes, Produits avaries, nes d'un siecle vaurien")
+	expected := enc.Encrypt(message)
+
+	t.Run("stream", func(t *testing.T) {
+		var s cipher.Stream = stream.NewCTR([]byte("YELLOW SUBMARINE"), 42)
+		encrypted := make([]byte, len(message))
+		s.XORKeyStream(encrypted[:3], message[:3])
+		s.XORKeyStream(encrypted[3:40], message[3:40])
+		s.XORKeyStream(encrypted[40:], message[40:])
+
+		assert.Equal(t, expected, encrypted)
+	})
+
+	t.Run("random access", func(t *testing.T) {
+		for _, offset := range []int{0, 1, 15, 16, 17, 40, len(message) - 1} {
+			for _, end := range []int{offset, offset + 1, offset + 16, len(message)} {
+				if end > len(message) {
+					continue
+				}
+
+				encrypted := make([]byte, end-offset)
+				enc.XORKeyStreamAt(encrypted, message[offset:end], uint64(offset))
+				assert.Equal(t, expected[offset:end], encrypted)
+			}
+		}
+	})
+}
+
+func TestCTRParallel(t *testing.T) {
+	// Force several goroutines even on a single CPU.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	key := []byte("YELLOW SUBMARINE")
+	nonce := []byte("12345678")
+	enc, err := stream.NewCTRWithLayout(key, nonce, stream.LayoutBigEndian64, 0)
+	require.NoError(t, err)
+
+	message := make([]byte, 10000*aes.BlockSize+5)
+	for i := range message {
+		message[i] = byte(i * 7)
+	}
+
+	b, _ := aes.NewCipher(key)
+	expected := make([]byte, len(message))
+	cipher.NewCTR(b, append(nonce, make([]byte, 8)...)).XORKeyStream(expected, message)
+
+	assert.Equal(t, expected, enc.Encrypt(message))
+
+	encrypted := make([]byte, len(message)-7)
+	enc.XORKeyStreamAt(encrypted, message[7:], 7)
+	assert.Equal(t, expected[7:], encrypted)
+}
+
+func BenchmarkCTR(b *testing.B) {
+	enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 0)
+	for _, size := range []int{16, 1 << 20} {
+		message := make([]byte, size)
+		for _, cpus := range paralleltest.BenchmarkCPUs() {
+			b.Run(fmt.Sprintf("%dB/%dCPU", size, cpus), func(b *testing.B) {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(cpus))
+
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					enc.Encrypt(message)
+				}
+			})
+		}
+	}
+}
+
+func TestPwnCTRNonceReuseLanguages(t *testing.T) {
+	lines := []string{
+		"Rappelez-vous l'objet que nous vîmes, mon âme,",
+		"Ce beau matin d'été si doux :",
+		"Au détour d'un sentier une charogne infâme",
+		"Sur un lit semé de cailloux,",
+		"Les jambes en l'air, comme une femme lubrique,",
+		"Brûlante et suant les poisons,",
+		"Ouvrait d'une façon nonchalante et cynique",
+		"Son ventre plein d'exhalaisons.",
+		"Le soleil rayonnait sur cette pourriture,",
+		"Comme afin de la cuire à point,",
+		"Et de rendre au centuple à la grande Nature",
+		"Tout ce qu'ensemble elle avait joint ;",
+	}
+
+	enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 0)
+	var ciphertexts [][]byte
+	for _, line := range lines {
+		ciphertexts = append(ciphertexts, enc.Encrypt([]byte(line)))
+	}
+
+	keystream, m := stream.PwnCTRNonceReuseLanguages(ciphertexts, score.Languages()...)
+	require.NotNil(t, m)
+	assert.Equal(t, "fr", m.Language)
+
+	// The beginning of the lines is covered by enough ciphertexts, but the
+	// first column only contains capitals which isolated characters can't
+	// tell apart from lower-case letters.
+	decrypted := xor.Bytes(ciphertexts[0], keystream)
+	assert.Equal(t, strings.ToLower(lines[0][:20]), strings.ToLower(string(decrypted[:20])))
 }
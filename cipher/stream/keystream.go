@@ -0,0 +1,39 @@
+package stream
+
+import (
+	"io"
+)
+
+// keystream turns a generator of fixed-size keystream blocks into a
+// cipher.Stream.
+type keystream struct {
+	next  func(block []byte)
+	block []byte
+	used  int
+}
+
+func newKeystream(blockLen int, next func(block []byte)) *keystream {
+	return &keystream{
+		next:  next,
+		block: make([]byte, blockLen),
+		used:  blockLen,
+	}
+}
+
+// XORKeyStream XORs each byte of src with the next keystream byte and writes
+// the result to dst.
+func (k *keystream) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+
+	for i, b := range src {
+		if k.used == len(k.block) {
+			k.next(k.block)
+			k.used = 0
+		}
+
+		dst[i] = b ^ k.block[k.used]
+		k.used++
+	}
+}
@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"crypto/cipher"
 	"encoding/binary"
 
 	"github.com/t-bast/cryptopals/prng"
@@ -40,3 +41,14 @@ func (e *PRNG) Encrypt(message []byte) []byte {
 func (e *PRNG) Decrypt(ciphertext []byte) []byte {
 	return e.Encrypt(ciphertext)
 }
+
+// Stream returns the keystream as a cipher.Stream, starting from its first
+// byte.
+// It can be used with xor.NewReader and xor.NewWriter to encrypt data
+// without loading it whole.
+func (e *PRNG) Stream() cipher.Stream {
+	mt := prng.NewMT19937(int(e.key))
+	return newKeystream(4, func(block []byte) {
+		binary.LittleEndian.PutUint32(block, uint32(mt.Rand()))
+	})
+}
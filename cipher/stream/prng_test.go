@@ -15,3 +15,16 @@ func TestPRNG(t *testing.T) {
 
 	assert.Equal(t, message, decrypted)
 }
+
+func TestPRNGStream(t *testing.T) {
+	enc := stream.NewPRNG(42)
+	message := []byte("WELCOME TO THE JUNGLE")
+
+	// Feed the keystream in uneven chunks to cross block boundaries.
+	s := enc.Stream()
+	encrypted := make([]byte, len(message))
+	s.XORKeyStream(encrypted[:3], message[:3])
+	s.XORKeyStream(encrypted[3:], message[3:])
+
+	assert.Equal(t, enc.Encrypt(message), encrypted)
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"math"
+
+	"github.com/t-bast/cryptopals/score"
+)
+
+// maxRefinePasses bounds the number of passes BreakNonceReuse makes over the
+// keystream once every byte has a first guess.
+const maxRefinePasses = 5
+
+// BreakNonceReuse recovers the keystream shared by ciphertexts that were
+// encrypted with the same nonce, over the length of the longest one.
+// Plaintexts are scored with the language model: each keystream byte is chosen
+// with the bytes around it as context, in every ciphertext that covers it, so
+// columns covered by many ciphertexts weigh more than the few that cover the
+// end of the longest ones.
+// It returns the keystream and, for each byte, the confidence in that byte
+// between 0 and 1: the probability of the chosen byte among all 256
+// candidates, weighted by the number of ciphertexts covering it.
+// Bytes covered by fewer than three ciphertexts are little more than guesses
+// and get a confidence of at most 0.5: they should be checked, for example
+// with a ManyTimePad.
+func BreakNonceReuse(ciphertexts [][]byte, m *score.Model) ([]byte, []float64) {
+	maxLength := 0
+	for _, ciphertext := range ciphertexts {
+		if len(ciphertext) > maxLength {
+			maxLength = len(ciphertext)
+		}
+	}
+
+	// Plaintexts start with a line break so that their first bytes are scored
+	// like the beginning of a line rather than without context.
+	plaintexts := make([][]byte, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		plaintexts[i] = make([]byte, len(ciphertext)+1)
+		plaintexts[i][0] = '\n'
+	}
+
+	keystream := make([]byte, maxLength)
+	confidence := make([]float64, maxLength)
+
+	// The first pass only sees the bytes before each position.
+	for i := range keystream {
+		keystream[i], confidence[i] = breakColumn(ciphertexts, plaintexts, i, 1, m)
+	}
+
+	// Later passes also see the bytes after each position, which fixes bytes
+	// that were wrongly guessed from their prefix.
+	for pass := 0; pass < maxRefinePasses; pass++ {
+		changed := false
+		for i := range keystream {
+			k, c := breakColumn(ciphertexts, plaintexts, i, m.Order(), m)
+			changed = changed || k != keystream[i]
+			keystream[i], confidence[i] = k, c
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return keystream, confidence
+}
+
+// breakColumn finds the most likely keystream byte at position i.
+// Candidates are scored with the log-probability of the window of bytes
+// starting at i in every plaintext, which are updated with the chosen byte.
+// Plaintexts are shifted by the line break they start with.
+func breakColumn(ciphertexts, plaintexts [][]byte, i, window int, m *score.Model) (byte, float64) {
+	var scores [256]float64
+	for k := 0; k < 256; k++ {
+		for j, ciphertext := range ciphertexts {
+			if i >= len(ciphertext) {
+				continue
+			}
+
+			plaintexts[j][i+1] = ciphertext[i] ^ byte(k)
+			for n := i + 1; n <= i+window && n <= len(ciphertext); n++ {
+				scores[k] += m.LogProbability(plaintexts[j], n)
+			}
+		}
+	}
+
+	best := 0
+	for k := range scores {
+		if scores[k] > scores[best] {
+			best = k
+		}
+	}
+
+	// The posterior probability of the best candidate is computed relative to
+	// it to avoid underflows.
+	total := 0.0
+	for k := range scores {
+		total += math.Exp(scores[k] - scores[best])
+	}
+
+	coverage := 0
+	for j, ciphertext := range ciphertexts {
+		if i < len(ciphertext) {
+			plaintexts[j][i+1] = ciphertext[i] ^ byte(best)
+			coverage++
+		}
+	}
+
+	// The model is easily overconfident when few plaintexts cover the column,
+	// so the confidence is weighted by the coverage.
+	return byte(best), float64(coverage) / float64(coverage+2) / total
+}
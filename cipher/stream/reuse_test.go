@@ -0,0 +1,79 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func TestBreakNonceReuse(t *testing.T) {
+	tests := []struct {
+		name  string
+		model *score.Model
+		lines []string
+	}{{
+		"english with symbols",
+		score.English(),
+		[]string{
+			"if (x > 0) { return x | mask; }\n",
+			"The quick brown fox jumps over the lazy dog.\n",
+			"She said: \"meet me at ~10 o'clock, by the {old} bridge\".\n",
+			"All that is gold does not glitter, not all those who wander are lost.\n",
+			"It was the best of times, it was the worst of times, it was the age of wisdom.\n",
+			"Whose woods these are I think I know; his house is in the village though.\n",
+			"There is a pleasure in the pathless woods, there is a rapture on the lonely shore.\n",
+			"Two roads diverged in a wood, and I took the one less traveled by.\n",
+			"Shall I compare thee to a summer's day? Thou art more lovely and more temperate.\n",
+			"We hold these truths to be self-evident, that all men are created equal.\n",
+		},
+	}, {
+		"french utf-8",
+		score.French(),
+		[]string{
+			"Rappelez-vous l'objet que nous vîmes, mon âme,",
+			"Ce beau matin d'été si doux :",
+			"Au détour d'un sentier une charogne infâme",
+			"Sur un lit semé de cailloux,",
+			"Les jambes en l'air, comme une femme lubrique,",
+			"Brûlante et suant les poisons,",
+			"Ouvrait d'une façon nonchalante et cynique",
+			"Son ventre plein d'exhalaisons.",
+			"Le soleil rayonnait sur cette pourriture,",
+			"Comme afin de la cuire à point,",
+			"Et de rendre au centuple à la grande Nature",
+			"Tout ce qu'ensemble elle avait joint ;",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := stream.NewCTR([]byte("YELLOW SUBMARINE"), 0)
+			var ciphertexts [][]byte
+			maxLength := 0
+			for _, line := range tt.lines {
+				ciphertexts = append(ciphertexts, enc.Encrypt([]byte(line)))
+				if len(line) > maxLength {
+					maxLength = len(line)
+				}
+			}
+
+			expected := enc.Encrypt(make([]byte, maxLength))
+			keystream, confidence := stream.BreakNonceReuse(ciphertexts, tt.model)
+			assert.Len(t, keystream, maxLength)
+			assert.Len(t, confidence, maxLength)
+
+			// Confident bytes must be right, and most bytes should be confident.
+			confident := 0
+			for i := range keystream {
+				if confidence[i] > 0.5 {
+					confident++
+					assert.Equalf(t, expected[i], keystream[i], "keystream byte %d", i)
+				}
+			}
+
+			assert.True(t, confident >= 9*maxLength/10, "only %d/%d confident bytes", confident, maxLength)
+		})
+	}
+}
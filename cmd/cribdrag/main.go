@@ -0,0 +1,254 @@
+// Command cribdrag helps decrypt ciphertexts that were encrypted with the same
+// keystream (for example CTR with a reused nonce) by dragging cribs across
+// them.
+//
+// It reads one ciphertext per line (base64 by default, or hex) and then reads
+// commands from the standard input:
+//
+//	drag <crib>                        show where the crib makes the most sense
+//	pin <ciphertext> <offset> <text>   pin the keystream implied by a plaintext
+//	key <offset> <hex>                 pin keystream bytes
+//	unpin <offset> <count>             forget pinned keystream bytes
+//	show                               decrypt with the pinned keystream
+//	quit
+//
+// Keystream bytes that aren't pinned are recovered statistically.
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func main() {
+	hexInput := flag.Bool("hex", false, "ciphertexts are hex-encoded instead of base64")
+	results := flag.Int("n", 5, "number of crib positions shown")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <ciphertexts file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ciphertexts, err := readCiphertexts(flag.Arg(0), *hexInput)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	s := &session{
+		pad:     stream.NewManyTimePad(ciphertexts),
+		results: *results,
+		out:     os.Stdout,
+	}
+
+	s.show()
+	scanner := bufio.NewScanner(os.Stdin)
+	for fmt.Fprint(s.out, "> "); scanner.Scan(); fmt.Fprint(s.out, "> ") {
+		if !s.run(scanner.Text()) {
+			return
+		}
+	}
+}
+
+func readCiphertexts(path string, hexInput bool) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decode := base64.StdEncoding.DecodeString
+	if hexInput {
+		decode = hex.DecodeString
+	}
+
+	var ciphertexts [][]byte
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		ciphertext, err := decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		ciphertexts = append(ciphertexts, ciphertext)
+	}
+
+	return ciphertexts, scanner.Err()
+}
+
+type session struct {
+	pad     *stream.ManyTimePad
+	results int
+	out     io.Writer
+}
+
+// run executes a command and returns false when the session is over.
+func (s *session) run(command string) bool {
+	name, args := splitWord(command)
+	var err error
+	switch name {
+	case "":
+	case "drag":
+		s.drag(args)
+	case "pin":
+		err = s.pin(args)
+	case "key":
+		err = s.key(args)
+	case "unpin":
+		err = s.unpin(args)
+	case "show":
+		s.show()
+	case "quit", "exit":
+		return false
+	default:
+		err = fmt.Errorf("unknown command %q", name)
+	}
+
+	if err != nil {
+		fmt.Fprintln(s.out, "error:", err)
+	}
+
+	return true
+}
+
+func (s *session) drag(crib string) {
+	matches := s.pad.Drag([]byte(crib), score.English())
+	if len(matches) > s.results {
+		matches = matches[:s.results]
+	}
+
+	for _, m := range matches {
+		fmt.Fprintf(s.out, "ciphertext %d, offset %d (score %.2f):\n", m.Ciphertext, m.Offset, m.Score)
+		for i, p := range m.Plaintexts {
+			fmt.Fprintf(s.out, "  %3d: %q\n", i, p)
+		}
+	}
+}
+
+func (s *session) pin(args string) error {
+	ciphertext, args := splitWord(args)
+	offset, plaintext := splitWord(args)
+	c, err := strconv.Atoi(ciphertext)
+	if err != nil {
+		return err
+	}
+
+	o, err := strconv.Atoi(offset)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pad.PinPlaintext(c, o, []byte(plaintext)); err != nil {
+		return err
+	}
+
+	s.show()
+	return nil
+}
+
+func (s *session) key(args string) error {
+	offset, keystream := splitWord(args)
+	o, err := strconv.Atoi(offset)
+	if err != nil {
+		return err
+	}
+
+	k, err := hex.DecodeString(keystream)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pad.Pin(o, k); err != nil {
+		return err
+	}
+
+	s.show()
+	return nil
+}
+
+func (s *session) unpin(args string) error {
+	offset, count := splitWord(args)
+	o, err := strconv.Atoi(offset)
+	if err != nil {
+		return err
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pad.Unpin(o, n); err != nil {
+		return err
+	}
+
+	s.show()
+	return nil
+}
+
+// show decrypts every ciphertext and marks the pinned keystream bytes.
+func (s *session) show() {
+	keystream := s.pad.Solve(score.LetterFrequencyScorer)
+
+	var pinned strings.Builder
+	for i := range keystream {
+		if s.pad.Pinned(i) {
+			pinned.WriteByte('^')
+		} else {
+			pinned.WriteByte(' ')
+		}
+	}
+
+	fmt.Fprintf(s.out, "keystream: %x\n", keystream)
+	for i, p := range s.pad.Plaintexts(keystream) {
+		fmt.Fprintf(s.out, "%3d: %s\n", i, printable(p))
+	}
+
+	fmt.Fprintf(s.out, "     %s\n", strings.TrimRight(pinned.String(), " "))
+}
+
+// printable replaces non-printable bytes so that columns stay aligned.
+func printable(b []byte) string {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		if c < 32 || c > 126 {
+			c = '.'
+		}
+
+		r[i] = c
+	}
+
+	return string(r)
+}
+
+// splitWord returns the first space-separated word and the rest of the text.
+func splitWord(text string) (string, string) {
+	text = strings.TrimLeft(text, " ")
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		return text[:i], text[i+1:]
+	}
+
+	return text, ""
+}
@@ -1,27 +1,102 @@
 package distance
 
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// ErrLengthMismatch is returned when comparing inputs of different lengths
+// with the Strict policy.
+var ErrLengthMismatch = errors.New("inputs have different lengths")
+
+// LengthPolicy defines how inputs of different lengths are compared.
+type LengthPolicy int
+
+// Length policies.
+const (
+	// Truncate ignores the extra bytes of the longest input.
+	Truncate LengthPolicy = 0
+	// PadZeros pads the shortest input with zero bytes, so every set bit of
+	// the extra bytes counts as a difference.
+	PadZeros LengthPolicy = 1
+	// Strict rejects inputs of different lengths.
+	Strict LengthPolicy = 2
+)
+
 // Hamming returns the hamming distance of two strings.
-// Highly inefficient implementation but simple enough.
+// Extra bytes of the longest string are ignored.
 func Hamming(s1, s2 string) int {
-	minLen := len(s1)
-	if len(s2) < len(s1) {
-		minLen = len(s2)
+	d, _ := HammingBytes([]byte(s1), []byte(s2), Truncate)
+	return d
+}
+
+// HammingBytes returns the number of bits that differ between a and b.
+// The policy defines how extra bytes are handled when the lengths differ.
+func HammingBytes(a, b []byte, policy LengthPolicy) (int, error) {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if len(a) != len(b) && policy == Strict {
+		return 0, ErrLengthMismatch
+	}
+
+	d := hamming(a, b[:len(a)])
+	if policy == PadZeros {
+		d += onesCount(b[len(a):])
+	}
+
+	return d, nil
+}
+
+// NormalizedHamming returns the fraction of bits that differ between a and b,
+// between 0 and 1.
+// The policy defines how extra bytes are handled when the lengths differ.
+func NormalizedHamming(a, b []byte, policy LengthPolicy) (float64, error) {
+	d, err := HammingBytes(a, b, policy)
+	if err != nil {
+		return 0, err
+	}
+
+	n := len(a)
+	if (policy == PadZeros && len(b) > n) || (policy == Truncate && len(b) < n) {
+		n = len(b)
 	}
 
+	if n == 0 {
+		return 0, nil
+	}
+
+	return float64(d) / float64(8*n), nil
+}
+
+// hamming returns the hamming distance of two inputs of the same length.
+// It compares 8-byte words at a time.
+func hamming(a, b []byte) int {
 	d := 0
-	for i := 0; i < minLen; i++ {
-		b1 := s1[i]
-		b2 := s2[i]
+	i := 0
+	for ; i+8 <= len(a); i += 8 {
+		d += bits.OnesCount64(binary.LittleEndian.Uint64(a[i:]) ^ binary.LittleEndian.Uint64(b[i:]))
+	}
 
-		for j := uint(0); j < 8; j++ {
-			bb1 := b1 >> j
-			bb2 := b2 >> j
+	for ; i < len(a); i++ {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
 
-			if (bb1 & 1) != (bb2 & 1) {
-				d++
-			}
-		}
+	return d
+}
+
+// onesCount returns the number of set bits.
+func onesCount(b []byte) int {
+	d := 0
+	i := 0
+	for ; i+8 <= len(b); i += 8 {
+		d += bits.OnesCount64(binary.LittleEndian.Uint64(b[i:]))
+	}
 
+	for ; i < len(b); i++ {
+		d += bits.OnesCount8(b[i])
 	}
 
 	return d
@@ -1,12 +1,94 @@
 package distance_test
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/distance"
 )
 
 func TestHamming(t *testing.T) {
 	assert.Equal(t, 37, distance.Hamming("this is a test", "wokka wokka!!!"))
 }
+
+func TestHammingBytes(t *testing.T) {
+	t.Run("matches bitwise implementation", func(t *testing.T) {
+		a := randomBytes(1037)
+		b := randomBytes(1037)
+		d, err := distance.HammingBytes(a, b, distance.Strict)
+		require.NoError(t, err)
+		assert.Equal(t, hammingBitwise(a, b), d)
+	})
+
+	t.Run("length policies", func(t *testing.T) {
+		a := []byte("this is a test")
+		b := []byte("wokka wokka!!!\x0f\xff")
+
+		d, err := distance.HammingBytes(a, b, distance.Truncate)
+		require.NoError(t, err)
+		assert.Equal(t, 37, d)
+
+		d, err = distance.HammingBytes(a, b, distance.PadZeros)
+		require.NoError(t, err)
+		assert.Equal(t, 37+4+8, d)
+
+		_, err = distance.HammingBytes(a, b, distance.Strict)
+		assert.Equal(t, distance.ErrLengthMismatch, err)
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		d, err := distance.NormalizedHamming([]byte{0x00, 0x00}, []byte{0xff, 0x00}, distance.Strict)
+		require.NoError(t, err)
+		assert.Equal(t, 0.5, d)
+
+		d, err = distance.NormalizedHamming([]byte{0x00}, []byte{0xff, 0xff}, distance.PadZeros)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, d)
+
+		d, err = distance.NormalizedHamming([]byte{0x00}, []byte{0xff, 0xff}, distance.Truncate)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, d)
+	})
+}
+
+// hammingBitwise is the original bit by bit implementation, kept as a
+// reference for tests and benchmarks.
+func hammingBitwise(b1, b2 []byte) int {
+	d := 0
+	for i := 0; i < len(b1); i++ {
+		for j := uint(0); j < 8; j++ {
+			if ((b1[i] >> j) & 1) != ((b2[i] >> j) & 1) {
+				d++
+			}
+		}
+	}
+
+	return d
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func BenchmarkHamming(b *testing.B) {
+	x := randomBytes(4 << 20)
+	y := randomBytes(4 << 20)
+
+	b.Run("bitwise", func(b *testing.B) {
+		b.SetBytes(int64(len(x)))
+		for i := 0; i < b.N; i++ {
+			hammingBitwise(x, y)
+		}
+	})
+
+	b.Run("words", func(b *testing.B) {
+		b.SetBytes(int64(len(x)))
+		for i := 0; i < b.N; i++ {
+			distance.HammingBytes(x, y, distance.Strict)
+		}
+	})
+}
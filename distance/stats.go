@@ -0,0 +1,52 @@
+package distance
+
+import (
+	"math"
+)
+
+// Histogram counts the occurrences of each byte value.
+func Histogram(b []byte) [256]int {
+	var h [256]int
+	for _, c := range b {
+		h[c]++
+	}
+
+	return h
+}
+
+// IndexOfCoincidence returns the probability that two bytes drawn at random
+// from b are equal.
+// It is about 0.0039 for uniformly random bytes and much higher for natural
+// language (around 0.065 for english letters).
+func IndexOfCoincidence(b []byte) float64 {
+	if len(b) < 2 {
+		return 0
+	}
+
+	coincidences := 0
+	for _, count := range Histogram(b) {
+		coincidences += count * (count - 1)
+	}
+
+	n := len(b)
+	return float64(coincidences) / float64(n*(n-1))
+}
+
+// Entropy returns the Shannon entropy of b, in bits per byte.
+// It is 8 for uniformly random bytes.
+func Entropy(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	e := 0.0
+	n := float64(len(b))
+	for _, count := range Histogram(b) {
+		if count > 0 {
+			p := float64(count) / n
+			e -= p * math.Log2(p)
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,31 @@
+package distance_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/cryptopals/distance"
+)
+
+func TestHistogram(t *testing.T) {
+	h := distance.Histogram([]byte("ICE ICE BABY"))
+	assert.Equal(t, 2, h['I'])
+	assert.Equal(t, 2, h[' '])
+	assert.Equal(t, 1, h['Y'])
+	assert.Equal(t, 0, h['Z'])
+}
+
+func TestIndexOfCoincidence(t *testing.T) {
+	assert.Equal(t, 0.0, distance.IndexOfCoincidence([]byte("a")))
+	assert.Equal(t, 1.0, distance.IndexOfCoincidence([]byte("aaaa")))
+	assert.Equal(t, 0.0, distance.IndexOfCoincidence([]byte("abcd")))
+
+	english := []byte("itwasthebestoftimesitwastheworstoftimesitwastheageofwisdomitwastheageoffoolishness")
+	assert.True(t, distance.IndexOfCoincidence(english) > distance.IndexOfCoincidence(randomBytes(len(english))))
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, distance.Entropy([]byte("aaaa")))
+	assert.Equal(t, 2.0, distance.Entropy([]byte("abcd")))
+	assert.InDelta(t, 8.0, distance.Entropy(randomBytes(1<<20)), 0.01)
+}
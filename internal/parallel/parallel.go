@@ -0,0 +1,51 @@
+// Package parallel splits work on contiguous ranges of items (typically
+// cipher blocks) across goroutines.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Split splits n items into contiguous ranges, at most one per available CPU
+// and each with at least min items.
+// It returns the boundaries of the ranges, from 0 to n: range i goes from
+// bounds[i] to bounds[i+1].
+func Split(n, min int) []int {
+	ranges := runtime.GOMAXPROCS(0)
+	if max := n / min; max < ranges {
+		ranges = max
+	}
+
+	if ranges <= 1 {
+		return []int{0, n}
+	}
+
+	bounds := make([]int, ranges+1)
+	for i := range bounds {
+		bounds[i] = n * i / ranges
+	}
+
+	return bounds
+}
+
+// Run calls f on every range of the boundaries returned by Split, each in its
+// own goroutine, and waits for all of them.
+// A single range runs on the calling goroutine.
+func Run(bounds []int, f func(i, start, end int)) {
+	if len(bounds) == 2 {
+		f(0, bounds[0], bounds[1])
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i+1 < len(bounds); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f(i, bounds[i], bounds[i+1])
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,33 @@
+package parallel_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/cryptopals/internal/parallel"
+)
+
+func TestSplit(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	assert.Equal(t, []int{0, 0}, parallel.Split(0, 10))
+	assert.Equal(t, []int{0, 19}, parallel.Split(19, 10))
+	assert.Equal(t, []int{0, 10, 20, 30}, parallel.Split(30, 10))
+	assert.Equal(t, []int{0, 25, 50, 75, 101}, parallel.Split(101, 10))
+}
+
+func TestRun(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	items := make([]int, 1000)
+	parallel.Run(parallel.Split(len(items), 100), func(i, start, end int) {
+		for j := start; j < end; j++ {
+			items[j] = i + 1
+		}
+	})
+
+	for j, item := range items {
+		assert.Equal(t, j*4/len(items)+1, item)
+	}
+}
@@ -0,0 +1,16 @@
+// Package paralleltest contains helpers for the tests and benchmarks of code
+// using package parallel.
+package paralleltest
+
+import "runtime"
+
+// BenchmarkCPUs returns the GOMAXPROCS values that benchmarks compare
+// sequential and parallel throughput with: 1 and the number of CPUs if there
+// are several.
+func BenchmarkCPUs() []int {
+	if n := runtime.NumCPU(); n > 1 {
+		return []int{1, n}
+	}
+
+	return []int{1}
+}
@@ -0,0 +1,124 @@
+package oracle
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Errors returned by bit-flipping attacks.
+var (
+	ErrFlipOutOfRange = errors.New("substitution doesn't fit in the ciphertext")
+	// ErrFlipSpansBlocks is returned when a CBC substitution spans several
+	// blocks: the block flipped for the second one would garble the first
+	// one.
+	ErrFlipSpansBlocks = errors.New("CBC substitution must fit in one block")
+	// ErrNoPreviousBlock is returned when a CBC substitution is in the first
+	// block, which would need flipping the IV.
+	ErrNoPreviousBlock = errors.New("CBC substitution in the first block needs the IV")
+	ErrFlipRejected    = errors.New("flipped ciphertext was rejected")
+)
+
+// FlipMode is how flipping ciphertext bits changes the plaintext.
+type FlipMode int
+
+const (
+	// FlipCTR flips the plaintext bits at the same position, like in CTR,
+	// OFB and other stream ciphers.
+	// It also works with CFB, which then garbles the next block.
+	FlipCTR FlipMode = iota
+	// FlipCBC flips the plaintext bits at the same position in the next
+	// block, and garbles the flipped block.
+	FlipCBC
+)
+
+// Flipper plans bit-flipping attacks on malleable ciphertexts.
+type Flipper struct {
+	Mode FlipMode
+	// BlockSize of the cipher, 16 if 0.
+	// It is only used in CBC mode.
+	BlockSize int
+}
+
+func (f Flipper) blockSize() int {
+	if f.BlockSize == 0 {
+		return 16
+	}
+
+	return f.BlockSize
+}
+
+// Flip returns a copy of the ciphertext whose plaintext has want instead of
+// known at the given offset.
+// The ciphertext doesn't include the IV in CBC mode: the block before the
+// substitution is garbled, so it must be a block the decrypting party
+// doesn't check.
+func (f Flipper) Flip(ciphertext []byte, offset int, known, want []byte) ([]byte, error) {
+	if len(known) != len(want) || offset < 0 || offset+len(want) > len(ciphertext) {
+		return nil, ErrFlipOutOfRange
+	}
+
+	flipAt := offset
+	if f.Mode == FlipCBC {
+		bs := f.blockSize()
+		if len(want) > 0 && offset/bs != (offset+len(want)-1)/bs {
+			return nil, ErrFlipSpansBlocks
+		}
+
+		if offset < bs {
+			return nil, ErrNoPreviousBlock
+		}
+
+		flipAt -= bs
+	}
+
+	flipped := append([]byte(nil), ciphertext...)
+	for i := range want {
+		flipped[flipAt+i] ^= known[i] ^ want[i]
+	}
+
+	return flipped, nil
+}
+
+// Plan returns the plaintext to have an oracle encrypt after a prefix of the
+// given length so that n bytes can be flipped, and their offset in the
+// plaintext.
+// They are preceded by a sacrificial block in CBC mode: it gets garbled and
+// keeps the prefix intact.
+// The planned plaintext is only made of 'A' characters, which oracles don't
+// escape.
+func (f Flipper) Plan(prefixLen, n int) ([]byte, int, error) {
+	if f.Mode != FlipCBC {
+		return bytes.Repeat([]byte{'A'}, n), prefixLen, nil
+	}
+
+	bs := f.blockSize()
+	if n > bs {
+		return nil, 0, ErrFlipSpansBlocks
+	}
+
+	fill := (bs-prefixLen%bs)%bs + bs
+	return bytes.Repeat([]byte{'A'}, fill+n), prefixLen + fill, nil
+}
+
+// Attack injects want into the plaintext of an oracle that encrypts chosen
+// plaintexts after a prefix of the given length: the planned plaintext is
+// encrypted and flipped into want, which the oracle would have escaped.
+// It returns the flipped ciphertext, or ErrFlipRejected if check rejects it.
+func (f Flipper) Attack(encrypt Encrypter, check func(ciphertext []byte) bool, prefixLen int, want []byte) ([]byte, error) {
+	plaintext, offset, err := f.Plan(prefixLen, len(want))
+	if err != nil {
+		return nil, err
+	}
+
+	known := plaintext[offset-prefixLen:]
+	flipped, err := f.Flip(encrypt(plaintext), offset, known, want)
+	if err != nil {
+		return nil, err
+	}
+
+	if !check(flipped) {
+		return nil, ErrFlipRejected
+	}
+
+	return flipped, nil
+}
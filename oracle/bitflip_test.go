@@ -0,0 +1,87 @@
+package oracle_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestFlipper(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	plaintext := []byte("user=bob;role=guest;comment=hello there, this is bob")
+
+	t.Run("CTR", func(t *testing.T) {
+		ctr := stream.NewCTR(key, 0)
+		flipped, err := oracle.Flipper{Mode: oracle.FlipCTR}.Flip(ctr.Encrypt(plaintext), 14, []byte("guest"), []byte("admin"))
+		require.NoError(t, err)
+		assert.Equal(t, "user=bob;role=admin;comment=hello there, this is bob", string(ctr.Decrypt(flipped)))
+	})
+
+	t.Run("CBC", func(t *testing.T) {
+		cbc := block.NewCBC(key, make([]byte, 16))
+		encrypted, err := cbc.Encrypt(plaintext)
+		require.NoError(t, err)
+
+		// The flipped block before the substitution is garbled.
+		flipped, err := oracle.Flipper{Mode: oracle.FlipCBC}.Flip(encrypted, 41, []byte("this"), []byte("here"))
+		require.NoError(t, err)
+		decrypted, err := cbc.Decrypt(flipped)
+		require.NoError(t, err)
+		assert.Equal(t, string(plaintext[:16]), string(decrypted[:16]))
+		assert.NotEqual(t, string(plaintext[16:32]), string(decrypted[16:32]))
+		assert.Equal(t, "o there, here is bob", string(decrypted[32:]))
+
+		f := oracle.Flipper{Mode: oracle.FlipCBC}
+		_, err = f.Flip(encrypted, 9, []byte("role"), []byte("ROLE"))
+		assert.Equal(t, oracle.ErrNoPreviousBlock, err)
+		_, err = f.Flip(encrypted, 30, []byte("ello"), []byte("ELLO"))
+		assert.Equal(t, oracle.ErrFlipSpansBlocks, err)
+		_, err = f.Flip(encrypted, 62, []byte("ello"), []byte("ELLO"))
+		assert.Equal(t, oracle.ErrFlipOutOfRange, err)
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		input, offset, err := oracle.Flipper{Mode: oracle.FlipCTR}.Plan(7, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "AAAAA", string(input))
+		assert.Equal(t, 7, offset)
+
+		for _, prefixLen := range []int{0, 7, 16, 31} {
+			input, offset, err := oracle.Flipper{Mode: oracle.FlipCBC}.Plan(prefixLen, 12)
+			require.NoError(t, err)
+			assert.Equal(t, 0, offset%16)
+			assert.Equal(t, prefixLen+len(input), offset+12)
+			assert.GreaterOrEqual(t, offset-prefixLen, 16)
+		}
+
+		_, _, err = oracle.Flipper{Mode: oracle.FlipCBC, BlockSize: 8}.Plan(3, 9)
+		assert.Equal(t, oracle.ErrFlipSpansBlocks, err)
+	})
+
+	t.Run("attack", func(t *testing.T) {
+		for _, prefixLen := range []int{0, 5, 16, 21} {
+			prefix := strings.Repeat("p", prefixLen)
+			cbc := block.NewCBC(key, make([]byte, 16))
+			encrypt := func(plaintext []byte) []byte {
+				sanitized := strings.NewReplacer(";", "%3B", "=", "%3D").Replace(string(plaintext))
+				encrypted, err := cbc.Encrypt([]byte(prefix + sanitized + ";suffix"))
+				require.NoError(t, err)
+				return encrypted
+			}
+
+			check := func(ciphertext []byte) bool {
+				decrypted, err := cbc.Decrypt(ciphertext)
+				return err == nil && strings.Contains(string(decrypted), ";admin=true;")
+			}
+
+			flipped, err := oracle.Flipper{Mode: oracle.FlipCBC}.Attack(encrypt, check, prefixLen, []byte(";admin=true;"))
+			require.NoError(t, err)
+			assert.True(t, check(flipped))
+		}
+	})
+}
@@ -1,12 +1,14 @@
 package oracle
 
 import (
-	"bytes"
+	"crypto/aes"
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
 // BlockMode of encryption.
@@ -14,17 +16,85 @@ type BlockMode int
 
 // Block encryption modes.
 const (
-	ECB BlockMode = 0
-	CBC BlockMode = 1
+	ECB  BlockMode = 0
+	CBC  BlockMode = 1
+	PCBC BlockMode = 2
+	CTS  BlockMode = 3
+	CFB  BlockMode = 4
+	OFB  BlockMode = 5
 )
 
+// BlockModes lists all the supported block encryption modes.
+var BlockModes = []BlockMode{ECB, CBC, PCBC, CTS, CFB, OFB}
+
+func (m BlockMode) String() string {
+	switch m {
+	case ECB:
+		return "ECB"
+	case CBC:
+		return "CBC"
+	case PCBC:
+		return "PCBC"
+	case CTS:
+		return "CTS"
+	case CFB:
+		return "CFB"
+	case OFB:
+		return "OFB"
+	default:
+		return fmt.Sprintf("BlockMode(%d)", int(m))
+	}
+}
+
+// Cipher returns the AES cipher for this mode with the given key and IV.
+// The IV is ignored in ECB mode.
+func (m BlockMode) Cipher(key, iv []byte) block.Cipher {
+	switch m {
+	case ECB:
+		return block.NewECB(key)
+	case CBC:
+		return block.NewCBC(key, iv)
+	case PCBC:
+		return block.NewPCBC(key, iv)
+	case CTS:
+		return block.NewCTS(key, iv)
+	case CFB:
+		return block.NewCFB(key, iv)
+	case OFB:
+		return block.NewOFB(key, iv)
+	default:
+		panic(fmt.Sprintf("unknown block mode %d", int(m)))
+	}
+}
+
+// CipherWithPadding returns the AES cipher for this mode with the given key,
+// IV and padding scheme.
+// The padding scheme is ignored by modes that don't pad.
+func (m BlockMode) CipherWithPadding(key, iv []byte, s padding.Scheme) block.Cipher {
+	switch m {
+	case ECB:
+		return &block.ECB{Key: key, Padding: s}
+	case CBC:
+		return &block.CBC{Key: key, IV: iv, Padding: s}
+	case PCBC:
+		return &block.PCBC{Key: key, IV: iv, Padding: s}
+	default:
+		return m.Cipher(key, iv)
+	}
+}
+
+// Padded returns true if the mode pads messages to full blocks.
+func (m BlockMode) Padded() bool {
+	return m == ECB || m == CBC || m == PCBC
+}
+
 // EncryptionOracle encrypts in a randomly chosen mode.
 type EncryptionOracle struct {
 	Mode BlockMode
 }
 
 // NewEncryptionOracle creates a new encryption oracles, with the block mode
-// set.
+// set to ECB or CBC.
 func NewEncryptionOracle() *EncryptionOracle {
 	rand.Seed(time.Now().UnixNano())
 	toss := rand.Intn(2)
@@ -32,7 +102,14 @@ func NewEncryptionOracle() *EncryptionOracle {
 	return &EncryptionOracle{Mode: BlockMode(toss)}
 }
 
-// Encrypt oracle that uses ECB or CBC block encryption.
+// NewEncryptionOracleWithMode creates a new encryption oracle that uses the
+// given block mode.
+func NewEncryptionOracleWithMode(mode BlockMode) *EncryptionOracle {
+	rand.Seed(time.Now().UnixNano())
+	return &EncryptionOracle{Mode: mode}
+}
+
+// Encrypt oracle that uses the oracle's block encryption mode.
 func (o *EncryptionOracle) Encrypt(message []byte) []byte {
 	key := make([]byte, 16)
 	iv := make([]byte, 16)
@@ -43,25 +120,57 @@ func (o *EncryptionOracle) Encrypt(message []byte) []byte {
 	prefix := make([]byte, prefixLen)
 	rand.Read(prefix)
 	suffixLen := 5 + rand.Intn(6)
+	if o.Mode == CTS && prefixLen+suffixLen < aes.BlockSize {
+		// CTS needs at least one block, even for empty messages.
+		suffixLen = aes.BlockSize - prefixLen
+	}
+
 	suffix := make([]byte, suffixLen)
 	rand.Read(suffix)
 
 	toEncrypt := append(prefix, message...)
 	toEncrypt = append(toEncrypt, suffix...)
 
-	if o.Mode == ECB {
-		return block.NewECB(key).Encrypt(toEncrypt)
+	encrypted, err := o.Mode.Cipher(key, iv).Encrypt(toEncrypt)
+	if err != nil {
+		panic(err)
 	}
 
-	return block.NewCBC(key, iv).Encrypt(toEncrypt)
+	return encrypted
 }
 
+// detectionAttempts is the number of encryptions used to decide whether a
+// mode pads messages.
+const detectionAttempts = 16
+
 // DetectEncryptionMode detects which block encryption the given oracle uses.
+// Modes that chosen plaintexts can't tell apart are reported as the first one
+// of DetectEncryptionModes: CBC for CBC and PCBC, CTS for CTS, CFB and OFB.
 func DetectEncryptionMode(oracle *EncryptionOracle) BlockMode {
-	encrypted := oracle.Encrypt([]byte(strings.Repeat("B", 64)))
-	if bytes.Equal(encrypted[16:32], encrypted[32:48]) {
-		return ECB
+	return DetectEncryptionModes(oracle)[0]
+}
+
+// DetectEncryptionModes returns the block encryption modes that are
+// consistent with the oracle's ciphertexts.
+// ECB is recognized by its repeated blocks, and the other modes by whether
+// they pad messages: the oracle uses a fresh key and IV for every message so
+// modes can't be told apart further.
+func DetectEncryptionModes(oracle *EncryptionOracle) []BlockMode {
+	message := []byte(strings.Repeat("B", 64))
+	encrypted := oracle.Encrypt(message)
+	if block.DetectECB(encrypted, 16).Repeats > 0 {
+		return []BlockMode{ECB}
+	}
+
+	// Padded modes always produce full blocks, while the others produce
+	// ciphertexts as long as the message with its random prefix and suffix.
+	for i := 0; i < detectionAttempts; i++ {
+		if len(encrypted)%16 != 0 {
+			return []BlockMode{CTS, CFB, OFB}
+		}
+
+		encrypted = oracle.Encrypt(message)
 	}
 
-	return CBC
+	return []BlockMode{CBC, PCBC}
 }
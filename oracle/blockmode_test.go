@@ -0,0 +1,77 @@
+package oracle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestEncryptionOracle(t *testing.T) {
+	for _, mode := range oracle.BlockModes {
+		t.Run(mode.String(), func(t *testing.T) {
+			o := oracle.NewEncryptionOracleWithMode(mode)
+			for i := 0; i < 100; i++ {
+				assert.NotPanics(t, func() { o.Encrypt(nil) })
+			}
+
+			assert.Contains(t, oracle.DetectEncryptionModes(o), mode)
+		})
+	}
+
+	t.Run("CTS short messages", func(t *testing.T) {
+		o := oracle.NewEncryptionOracleWithMode(oracle.CTS)
+		for i := 0; i < 100; i++ {
+			assert.GreaterOrEqual(t, len(o.Encrypt(nil)), 16)
+		}
+	})
+}
+
+func TestCBCOracleModes(t *testing.T) {
+	prefixLen := len("comment1=cooking%20MCs;userdata=")
+
+	t.Run("PCBC", func(t *testing.T) {
+		// The garbled block propagates to every following block.
+		o := oracle.NewCBCOracleWithMode(oracle.PCBC)
+		encrypt := func(plaintext []byte) []byte { return o.Encrypt(string(plaintext)) }
+		_, err := oracle.Flipper{Mode: oracle.FlipCBC}.Attack(encrypt, o.CheckAdmin, prefixLen, []byte(";admin=true;"))
+		assert.Equal(t, oracle.ErrFlipRejected, err)
+	})
+
+	t.Run("CFB", func(t *testing.T) {
+		// Flipped ciphertext bits flip the same plaintext bits, and only
+		// garble the next block.
+		o := oracle.NewCBCOracleWithMode(oracle.CFB)
+		encrypt := func(plaintext []byte) []byte { return o.Encrypt(string(plaintext)) }
+		_, err := oracle.Flipper{Mode: oracle.FlipCTR}.Attack(encrypt, o.CheckAdmin, prefixLen, []byte(";admin=true;"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestPaddingOracle(t *testing.T) {
+	t.Run("constant time", func(t *testing.T) {
+		// Validating in constant time doesn't help: the oracle still tells
+		// whether the padding is valid.
+		s, err := padding.ConstantTime(padding.SchemePKCS7)
+		require.NoError(t, err)
+
+		o, encrypted := oracle.NewPaddingOracleWithScheme(oracle.CBC, s)
+		decrypted, _, err := oracle.DecryptWithPaddingOracle(o.CheckPadding, encrypted, oracle.PaddingAttackOptions{IV: o.IV})
+		require.NoError(t, err)
+		assert.Equal(t, string(o.Secret), string(decrypted))
+	})
+
+	t.Run("forgery", func(t *testing.T) {
+		// The oracle uses a fixed IV, so the forged IV is sent as the first
+		// block and decrypts to garbage.
+		o, _ := oracle.NewPaddingOracle()
+		forgedIV, forged, _, err := oracle.EncryptWithPaddingOracle(o.CheckPadding, []byte("000010Now that the party is over"), oracle.ForgeOptions{})
+		require.NoError(t, err)
+
+		decrypted, _, err := oracle.DecryptWithPaddingOracle(o.CheckPadding, append(forgedIV, forged...), oracle.PaddingAttackOptions{IV: o.IV})
+		require.NoError(t, err)
+		assert.Equal(t, "000010Now that the party is over", string(decrypted[16:]))
+	})
+}
@@ -0,0 +1,49 @@
+package oracle
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// cipherCache caches the cipher an oracle builds from its exported fields,
+// so that its key schedule is reused as long as they don't change.
+// It is safe for concurrent use.
+type cipherCache struct {
+	cached atomic.Value
+}
+
+type cachedCipher struct {
+	params [][]byte
+	c      interface{}
+}
+
+// get returns the cipher built from the given parameters.
+// It's only built again when they changed since the last call.
+func (cc *cipherCache) get(build func() interface{}, params ...[]byte) interface{} {
+	if cached, ok := cc.cached.Load().(*cachedCipher); ok && sameParams(cached.params, params) {
+		return cached.c
+	}
+
+	copied := make([][]byte, len(params))
+	for i, p := range params {
+		copied[i] = append([]byte(nil), p...)
+	}
+
+	c := build()
+	cc.cached.Store(&cachedCipher{params: copied, c: c})
+	return c
+}
+
+func sameParams(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
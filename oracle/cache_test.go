@@ -0,0 +1,50 @@
+package oracle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestOracleFields(t *testing.T) {
+	key1 := []byte("YELLOW SUBMARINE")
+	key2 := []byte("PURPLE SUNRISE!!")
+	iv := make([]byte, 16)
+	message := "comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon"
+
+	t.Run("CBC", func(t *testing.T) {
+		o := &oracle.CBCOracle{Key: key1, IV: iv, Mode: oracle.CBC}
+		expected, err := block.NewCBC(key1, iv).Encrypt([]byte(message))
+		require.NoError(t, err)
+		assert.Equal(t, expected, o.Encrypt("foo"))
+
+		// Changing the fields changes the cipher.
+		o.Key = key2
+		expected, err = block.NewCBC(key2, iv).Encrypt([]byte(message))
+		require.NoError(t, err)
+		assert.Equal(t, expected, o.Encrypt("foo"))
+
+		o.Mode = oracle.OFB
+		expected, err = block.NewOFB(key2, iv).Encrypt([]byte(message))
+		require.NoError(t, err)
+		assert.Equal(t, expected, o.Encrypt("foo"))
+	})
+
+	t.Run("CTR", func(t *testing.T) {
+		o := &oracle.CTROracle{Key: key1, Nonce: 1}
+		assert.Equal(t, stream.NewCTR(key1, 1).Encrypt([]byte(message)), o.Encrypt("foo"))
+
+		o.Nonce = 2
+		assert.Equal(t, stream.NewCTR(key1, 2).Encrypt([]byte(message)), o.Encrypt("foo"))
+	})
+
+	t.Run("padding", func(t *testing.T) {
+		// Without a key, nothing decrypts.
+		o := &oracle.PaddingOracle{}
+		assert.False(t, o.CheckPadding(make([]byte, 32)))
+	})
+}
@@ -10,20 +10,31 @@ import (
 
 // CBCOracle encrypts data with a known prefix and suffix.
 // It escapes some characters.
+// It uses CBC by default, but other modes can be selected to compare how they
+// resist bit-flipping.
 type CBCOracle struct {
-	Key []byte
-	IV  []byte
+	Key  []byte
+	IV   []byte
+	Mode BlockMode
+
+	cache cipherCache
 }
 
 // NewCBCOracle creates a key for a CBC oracle.
 func NewCBCOracle() *CBCOracle {
+	return NewCBCOracleWithMode(CBC)
+}
+
+// NewCBCOracleWithMode creates a key for an oracle that uses the given block
+// mode.
+func NewCBCOracleWithMode(mode BlockMode) *CBCOracle {
 	rand.Seed(time.Now().UnixNano())
 	key := make([]byte, 16)
 	rand.Read(key)
 
 	iv := [16]byte{}
 
-	return &CBCOracle{Key: key, IV: iv[:]}
+	return &CBCOracle{Key: key, IV: iv[:], Mode: mode}
 }
 
 // Encrypt a message, escaping ";" and "=" and adding prefix and suffix.
@@ -32,15 +43,26 @@ func (o *CBCOracle) Encrypt(plaintext string) []byte {
 	sanitized := strings.Replace(temp, ";", "';'", -1)
 	toEncrypt := "comment1=cooking%20MCs;userdata=" + sanitized + ";comment2=%20like%20a%20pound%20of%20bacon"
 
-	cbc := block.NewCBC(o.Key, o.IV)
-	return cbc.Encrypt([]byte(toEncrypt))
+	encrypted, err := o.cipher().Encrypt([]byte(toEncrypt))
+	if err != nil {
+		panic(err)
+	}
+
+	return encrypted
 }
 
 // CheckAdmin decrypts the given ciphertext and checks if ";admin=true;" has
 // been successfully inserted.
+// Ciphertexts that can't be decrypted are rejected.
 func (o *CBCOracle) CheckAdmin(ciphertext []byte) bool {
-	cbc := block.NewCBC(o.Key, o.IV)
-	decrypted := string(cbc.Decrypt(ciphertext))
+	decrypted, err := o.cipher().Decrypt(ciphertext)
+	if err != nil {
+		return false
+	}
+
+	return strings.Index(string(decrypted), ";admin=true;") >= 0
+}
 
-	return strings.Index(decrypted, ";admin=true;") >= 0
+func (o *CBCOracle) cipher() block.Cipher {
+	return o.cache.get(func() interface{} { return o.Mode.Cipher(o.Key, o.IV) }, []byte{byte(o.Mode)}, o.Key, o.IV).(block.Cipher)
 }
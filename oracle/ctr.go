@@ -1,6 +1,7 @@
 package oracle
 
 import (
+	"encoding/binary"
 	"math/rand"
 	"strings"
 	"time"
@@ -13,6 +14,8 @@ import (
 type CTROracle struct {
 	Key   []byte
 	Nonce uint64
+
+	cache cipherCache
 }
 
 // NewCTROracle creates a key for a CTR oracle.
@@ -32,15 +35,19 @@ func (o *CTROracle) Encrypt(plaintext string) []byte {
 	sanitized := strings.Replace(temp, ";", "';'", -1)
 	toEncrypt := "comment1=cooking%20MCs;userdata=" + sanitized + ";comment2=%20like%20a%20pound%20of%20bacon"
 
-	ctr := stream.NewCTR(o.Key, o.Nonce)
-	return ctr.Encrypt([]byte(toEncrypt))
+	return o.ctr().Encrypt([]byte(toEncrypt))
 }
 
 // CheckAdmin decrypts the given ciphertext and checks if ";admin=true;" has
 // been successfully inserted.
 func (o *CTROracle) CheckAdmin(ciphertext []byte) bool {
-	ctr := stream.NewCTR(o.Key, o.Nonce)
-	decrypted := string(ctr.Decrypt(ciphertext))
+	decrypted := string(o.ctr().Decrypt(ciphertext))
 
 	return strings.Index(decrypted, ";admin=true;") >= 0
 }
+
+func (o *CTROracle) ctr() *stream.CTR {
+	nonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonce, o.Nonce)
+	return o.cache.get(func() interface{} { return stream.NewCTR(o.Key, o.Nonce) }, o.Key, nonce).(*stream.CTR)
+}
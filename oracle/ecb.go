@@ -1,7 +1,6 @@
 package oracle
 
 import (
-	"bytes"
 	"encoding/base64"
 	"math/rand"
 	"time"
@@ -15,6 +14,7 @@ import (
 type ECBOracle struct {
 	Key    []byte
 	secret []byte
+	cache  cipherCache
 }
 
 // NewECBOracle creates a random key and an oracle that uses that key.
@@ -30,52 +30,14 @@ func NewECBOracle() *ECBOracle {
 
 // Encrypt the given message to which we append the secret message.
 func (o *ECBOracle) Encrypt(message []byte) []byte {
-	ecb := block.NewECB(o.Key)
-	return ecb.Encrypt(append(message, o.secret...))
-}
-
-// DetectECBSecret extracts the secret message from the oracle.
-func DetectECBSecret(oracle *ECBOracle) []byte {
-	secretLength := len(oracle.Encrypt(nil))
-	secret := make([]byte, secretLength)
-
-	currentBlock := 0
-	currentIndex := 0
-
-	for currentBlock*16+currentIndex < secretLength {
-		// Initialize mask with last known secret bytes, or fixed values.
-		mask := make([]byte, 15)
-		for i := 0; i < 15; i++ {
-			if currentBlock*16+currentIndex >= 15-i {
-				mask[i] = secret[currentBlock*16+currentIndex-15+i]
-			} else {
-				mask[i] = 'A'
-			}
-		}
-
-		// Record encryption results for each possible byte added to the mask.
-		dict := make([][]byte, 256)
-		for i := 0; i < 256; i++ {
-			dict[i] = oracle.Encrypt(append(mask[:], byte(i)))[0:16]
-		}
-
-		// Encrypt with the next unknown byte.
-		e := oracle.Encrypt(mask[:15-currentIndex])
-
-		// Find the pre-image in dictionary.
-		for i, v := range dict {
-			if bytes.Equal(v, e[16*currentBlock:16*(currentBlock+1)]) {
-				secret[16*currentBlock+currentIndex] = byte(i)
-				break
-			}
-		}
-
-		currentIndex++
-		if currentIndex == 16 {
-			currentBlock++
-			currentIndex = 0
-		}
+	encrypted, err := o.ecb().Encrypt(append(message, o.secret...))
+	if err != nil {
+		panic(err)
 	}
 
-	return secret
+	return encrypted
+}
+
+func (o *ECBOracle) ecb() *block.ECB {
+	return o.cache.get(func() interface{} { return block.NewECB(o.Key) }, o.Key).(*block.ECB)
 }
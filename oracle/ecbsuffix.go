@@ -0,0 +1,247 @@
+package oracle
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Errors returned by DecryptECBSuffix.
+var (
+	ErrNotECB = errors.New("oracle doesn't encrypt with ECB")
+	// ErrNotAligned is returned when the random prefix of an oracle never
+	// lets the plaintext start on a block boundary.
+	ErrNotAligned = errors.New("couldn't align the plaintext after the random prefix")
+	// ErrNoMatch is returned when a ciphertext block isn't in the dictionary,
+	// which happens when the oracle doesn't encrypt deterministically.
+	ErrNoMatch = errors.New("ciphertext block isn't in the dictionary")
+)
+
+// maxAlignAttempts bounds the number of calls made to align a single
+// plaintext after a random prefix, in blocks: each call succeeds with
+// probability one in the block size when the prefix length is random.
+const maxAlignAttempts = 64
+
+// DecryptECBSuffix recovers the secret suffix that an ECB oracle appends to the
+// plaintext, one byte at a time: the plaintext is chosen so that one unknown
+// byte ends a block, whose ciphertext is looked up in a dictionary of the 256
+// possible blocks.
+// The block size, prefix length and secret length come from ProfileOracle.
+// Oracles that prepend a new random prefix to every plaintext are supported:
+// every call is then retried until the plaintext starts on a block boundary.
+// Dictionaries are encrypted in a single call and cached with the ciphertexts
+// of the secret, so it makes about one oracle call per byte instead of 256.
+func DecryptECBSuffix(e Encrypter) ([]byte, error) {
+	p, err := ProfileOracle(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.ECB {
+		return nil, ErrNotECB
+	}
+
+	a := &ecbSuffixAttack{
+		blockSize:    p.BlockSize,
+		dictionaries: make(map[string]map[string]byte),
+		targets:      make(map[int][]byte),
+	}
+
+	if p.Deterministic {
+		a.query = alignedAfterPrefix(e, p.BlockSize, p.PrefixLen)
+	} else {
+		a.query = alignedAfterRandomPrefix(e, p.BlockSize)
+	}
+
+	return a.decrypt()
+}
+
+// alignedAfterPrefix returns the ciphertext blocks that follow the fixed
+// prefix, padding the plaintext to start on a block boundary.
+func alignedAfterPrefix(e Encrypter, blockSize, prefixLen int) func([]byte) ([]byte, error) {
+	fill := make([]byte, (blockSize-prefixLen%blockSize)%blockSize)
+	skip := prefixLen + len(fill)
+
+	return func(plaintext []byte) ([]byte, error) {
+		return e(append(fill[:len(fill):len(fill)], plaintext...))[skip:], nil
+	}
+}
+
+// alignedAfterRandomPrefix returns the ciphertext blocks that follow a random
+// prefix.
+// Two blocks of marker bytes are inserted before the plaintext, after a
+// separator that keeps the prefix from extending them: the plaintext starts
+// on a block boundary when they give two marker ciphertext blocks.
+func alignedAfterRandomPrefix(e Encrypter, blockSize int) func([]byte) ([]byte, error) {
+	markers := make(map[byte][]byte)
+	return func(plaintext []byte) ([]byte, error) {
+		// The plaintext must not extend the markers either.
+		marker := byte(0xff)
+		if len(plaintext) > 0 && plaintext[0] == marker {
+			marker = 0xfe
+		}
+
+		if _, ok := markers[marker]; !ok {
+			c, err := markerBlock(e, blockSize, marker)
+			if err != nil {
+				return nil, err
+			}
+
+			markers[marker] = c
+		}
+
+		for attempt := 0; attempt < maxAlignAttempts*blockSize; attempt++ {
+			// The separator length varies in case the random prefix has a
+			// fixed length.
+			probe := make([]byte, 1+attempt%blockSize, 1+attempt%blockSize+2*blockSize+len(plaintext))
+			probe = append(probe, bytes.Repeat([]byte{marker}, 2*blockSize)...)
+			c := e(append(probe, plaintext...))
+
+			// Only the first marker block counts: when the markers aren't
+			// aligned it is followed by a block of plaintext.
+			for start := 0; start+2*blockSize <= len(c); start += blockSize {
+				if bytes.Equal(c[start:start+blockSize], markers[marker]) {
+					if bytes.Equal(c[start+blockSize:start+2*blockSize], markers[marker]) {
+						return c[start+2*blockSize:], nil
+					}
+
+					break
+				}
+			}
+		}
+
+		return nil, ErrNotAligned
+	}
+}
+
+// markerBlock returns the ciphertext of a block of marker bytes: every block
+// inside a long enough run of them is one, whatever the prefix length.
+func markerBlock(e Encrypter, blockSize int, marker byte) ([]byte, error) {
+	probe := append([]byte{0}, bytes.Repeat([]byte{marker}, 3*blockSize)...)
+	c := e(probe)
+	for start := 0; start+2*blockSize <= len(c); start += blockSize {
+		if bytes.Equal(c[start:start+blockSize], c[start+blockSize:start+2*blockSize]) {
+			return c[start : start+blockSize], nil
+		}
+	}
+
+	return nil, ErrNotAligned
+}
+
+// ecbSuffixAttack decrypts the suffix of an oracle whose plaintext starts on
+// a block boundary.
+type ecbSuffixAttack struct {
+	blockSize int
+	query     func(plaintext []byte) ([]byte, error)
+	// dictionaries maps the bytes preceding the unknown byte to the
+	// ciphertext blocks of the 256 possible blocks.
+	dictionaries map[string]map[string]byte
+	// targets maps a fill length to the ciphertext of that fill followed by
+	// the suffix.
+	targets map[int][]byte
+}
+
+func (a *ecbSuffixAttack) decrypt() ([]byte, error) {
+	suffixLen, err := a.suffixLen()
+	if err != nil {
+		return nil, err
+	}
+
+	// The known plaintext starts with the fill, so that the first bytes of
+	// the suffix are preceded by it.
+	known := bytes.Repeat([]byte{'A'}, a.blockSize-1)
+	for i := 0; i < suffixLen; i++ {
+		// Shift the suffix so that byte i ends a block.
+		fillLen := a.blockSize - 1 - i%a.blockSize
+		target, err := a.target(fillLen)
+		if err != nil {
+			return nil, err
+		}
+
+		blockStart := (fillLen + i) / a.blockSize * a.blockSize
+		if blockStart+a.blockSize > len(target) {
+			return nil, ErrNoMatch
+		}
+
+		dict, err := a.dictionary(known[len(known)-a.blockSize+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		b, ok := dict[string(target[blockStart:blockStart+a.blockSize])]
+		if !ok {
+			return nil, ErrNoMatch
+		}
+
+		known = append(known, b)
+	}
+
+	return known[a.blockSize-1:], nil
+}
+
+// suffixLen finds the length of the suffix from the plaintext length that
+// adds a block of padding.
+func (a *ecbSuffixAttack) suffixLen() (int, error) {
+	base, err := a.query(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for n := 1; n <= a.blockSize; n++ {
+		c, err := a.query(make([]byte, n))
+		if err != nil {
+			return 0, err
+		}
+
+		if len(c) > len(base) {
+			return len(base) - n, nil
+		}
+	}
+
+	return 0, ErrNotECB
+}
+
+// target returns the ciphertext of the fill followed by the suffix.
+func (a *ecbSuffixAttack) target(fillLen int) ([]byte, error) {
+	if c, ok := a.targets[fillLen]; ok {
+		return c, nil
+	}
+
+	c, err := a.query(bytes.Repeat([]byte{'A'}, fillLen))
+	if err != nil {
+		return nil, err
+	}
+
+	a.targets[fillLen] = c
+	return c, nil
+}
+
+// dictionary returns the ciphertext blocks of the prefix followed by every
+// possible byte, encrypted with a single oracle call.
+func (a *ecbSuffixAttack) dictionary(prefix []byte) (map[string]byte, error) {
+	if dict, ok := a.dictionaries[string(prefix)]; ok {
+		return dict, nil
+	}
+
+	plaintext := make([]byte, 0, 256*a.blockSize)
+	for b := 0; b < 256; b++ {
+		plaintext = append(plaintext, prefix...)
+		plaintext = append(plaintext, byte(b))
+	}
+
+	c, err := a.query(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c) < len(plaintext) {
+		return nil, ErrNoMatch
+	}
+
+	dict := make(map[string]byte, 256)
+	for b := 0; b < 256; b++ {
+		dict[string(c[b*a.blockSize:(b+1)*a.blockSize])] = byte(b)
+	}
+
+	a.dictionaries[string(prefix)] = dict
+	return dict, nil
+}
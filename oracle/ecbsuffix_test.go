@@ -0,0 +1,72 @@
+package oracle_test
+
+import (
+	"crypto/des"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestDecryptECBSuffix(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	secret := []byte("Rollin' in my 5.0\xff\x00 With my rag-top down so my hair can blow")
+
+	t.Run("prefix lengths", func(t *testing.T) {
+		for _, prefixLen := range []int{0, 1, 15, 16, 21} {
+			calls := 0
+			e := withAffixes(block.NewECB(key), make([]byte, prefixLen), secret)
+			decrypted, err := oracle.DecryptECBSuffix(func(plaintext []byte) []byte {
+				calls++
+				return e(plaintext)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, secret, decrypted)
+			assert.Less(t, calls, 2*len(secret)+100)
+		}
+	})
+
+	t.Run("random prefix", func(t *testing.T) {
+		e := withAffixes(block.NewECB(key), nil, secret)
+		decrypted, err := oracle.DecryptECBSuffix(func(plaintext []byte) []byte {
+			prefix := make([]byte, rand.Intn(40))
+			rand.Read(prefix)
+			return e(append(prefix, plaintext...))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, secret, decrypted)
+	})
+
+	t.Run("random prefix of fixed length", func(t *testing.T) {
+		e := withAffixes(block.NewECB(key), nil, secret)
+		decrypted, err := oracle.DecryptECBSuffix(func(plaintext []byte) []byte {
+			prefix := make([]byte, 7)
+			rand.Read(prefix)
+			return e(append(prefix, plaintext...))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, secret, decrypted)
+	})
+
+	t.Run("DES", func(t *testing.T) {
+		b, err := des.NewCipher(key[:8])
+		require.NoError(t, err)
+
+		decrypted, err := oracle.DecryptECBSuffix(func(plaintext []byte) []byte {
+			message := padding.PKCS7(append(append([]byte("pre"), plaintext...), secret...), 8)
+			block.NewECBEncrypter(b).CryptBlocks(message, message)
+			return message
+		})
+		require.NoError(t, err)
+		assert.Equal(t, secret, decrypted)
+	})
+
+	t.Run("not ECB", func(t *testing.T) {
+		_, err := oracle.DecryptECBSuffix(withAffixes(block.NewCBC(key, make([]byte, 16)), nil, secret))
+		assert.Equal(t, oracle.ErrNotECB, err)
+	})
+}
@@ -0,0 +1,146 @@
+package oracle
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+// Errors returned by the IV=key oracle and attack.
+var (
+	// ErrInvalidPlaintext is matched by InvalidPlaintextError.
+	ErrInvalidPlaintext = errors.New("invalid plaintext")
+	// ErrPlaintextAccepted is returned when the crafted ciphertext decrypts to
+	// a plaintext that the checker accepts, so it doesn't leak it.
+	ErrPlaintextAccepted = errors.New("crafted plaintext was accepted")
+)
+
+// InvalidPlaintextError is returned when a decrypted plaintext isn't valid
+// text.
+// It carries the whole plaintext, the way a careless web service would echo
+// it in its error page.
+type InvalidPlaintextError struct {
+	Plaintext []byte
+}
+
+func (e InvalidPlaintextError) Error() string {
+	return fmt.Sprintf("invalid plaintext: %q", e.Plaintext)
+}
+
+// Is makes InvalidPlaintextError match ErrInvalidPlaintext.
+func (e InvalidPlaintextError) Is(target error) bool {
+	return target == ErrInvalidPlaintext
+}
+
+// IVKeyOracle encrypts data with a known prefix and suffix with CBC, using
+// the key as IV.
+// It escapes some characters.
+type IVKeyOracle struct {
+	Key []byte
+	// AllowUTF8 accepts plaintexts that are valid UTF-8.
+	// Otherwise only ASCII plaintexts are accepted.
+	AllowUTF8 bool
+
+	cache cipherCache
+}
+
+// NewIVKeyOracle returns an oracle with a random key, which it also uses as
+// IV.
+func NewIVKeyOracle() *IVKeyOracle {
+	rand.Seed(time.Now().UnixNano())
+	key := make([]byte, 16)
+	rand.Read(key)
+
+	return &IVKeyOracle{Key: key}
+}
+
+// Encrypt a message, escaping ";" and "=" and adding prefix and suffix.
+func (o *IVKeyOracle) Encrypt(plaintext string) []byte {
+	temp := strings.Replace(plaintext, "=", "'='", -1)
+	sanitized := strings.Replace(temp, ";", "';'", -1)
+	toEncrypt := "comment1=cooking%20MCs;userdata=" + sanitized + ";comment2=%20like%20a%20pound%20of%20bacon"
+
+	encrypted, err := o.cbc().Encrypt([]byte(toEncrypt))
+	if err != nil {
+		panic(err)
+	}
+
+	return encrypted
+}
+
+// Check decrypts the given ciphertext and verifies that it's valid text.
+// It returns an InvalidPlaintextError containing the decrypted plaintext if
+// it isn't, and padding errors as is.
+func (o *IVKeyOracle) Check(ciphertext []byte) error {
+	decrypted, err := o.cbc().Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+
+	if o.AllowUTF8 {
+		if !utf8.Valid(decrypted) {
+			return InvalidPlaintextError{Plaintext: decrypted}
+		}
+
+		return nil
+	}
+
+	for _, b := range decrypted {
+		if b >= utf8.RuneSelf {
+			return InvalidPlaintextError{Plaintext: decrypted}
+		}
+	}
+
+	return nil
+}
+
+func (o *IVKeyOracle) cbc() *block.CBC {
+	return o.cache.get(func() interface{} { return block.NewCBC(o.Key, o.Key) }, o.Key).(*block.CBC)
+}
+
+// PlaintextChecker decrypts a ciphertext and returns an InvalidPlaintextError
+// when it rejects the plaintext.
+type PlaintextChecker func(ciphertext []byte) error
+
+// RecoverKeyFromIVReuse recovers the key of an AES-128 CBC checker that uses
+// its key as IV, from a ciphertext of at least two blocks.
+// It sends C1 || 0 || C1 followed by the last two blocks, which keeps the
+// padding valid: the first block decrypts to P1 = D(C1) ^ key and the third
+// one to D(C1), so the key is their xor.
+// The random third block is very unlikely to be valid text, so the checker
+// rejects it and leaks the plaintext.
+func RecoverKeyFromIVReuse(check PlaintextChecker, ciphertext []byte) ([]byte, error) {
+	bs := aes.BlockSize
+	if len(ciphertext) < 2*bs || len(ciphertext)%bs != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	var crafted []byte
+	crafted = append(crafted, ciphertext[:bs]...)
+	crafted = append(crafted, make([]byte, bs)...)
+	crafted = append(crafted, ciphertext[:bs]...)
+	crafted = append(crafted, ciphertext[len(ciphertext)-2*bs:]...)
+
+	err := check(crafted)
+	if err == nil {
+		return nil, ErrPlaintextAccepted
+	}
+
+	var invalid InvalidPlaintextError
+	if !errors.As(err, &invalid) {
+		return nil, err
+	}
+
+	if len(invalid.Plaintext) < 3*bs {
+		return nil, ErrInvalidPlaintext
+	}
+
+	return xor.Bytes(invalid.Plaintext[:bs], invalid.Plaintext[2*bs:3*bs]), nil
+}
@@ -0,0 +1,64 @@
+package oracle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestIVKeyOracle(t *testing.T) {
+	o := oracle.NewIVKeyOracle()
+	ciphertext := o.Encrypt("some;user=data")
+	assert.NoError(t, o.Check(ciphertext))
+
+	// Flipping a bit of the first block garbles it.
+	ciphertext[3] ^= 0x80
+	err := o.Check(ciphertext)
+	assert.ErrorIs(t, err, oracle.ErrInvalidPlaintext)
+	var invalid oracle.InvalidPlaintextError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "some';'user'='data;comment2=%20like%20a%20pound%20of%20bacon", string(invalid.Plaintext[32:]))
+}
+
+func TestIVKeyOracleKey(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	o := &oracle.IVKeyOracle{Key: key}
+	expected, err := block.NewCBC(key, key).Encrypt([]byte("comment1=cooking%20MCs;userdata=;comment2=%20like%20a%20pound%20of%20bacon"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, o.Encrypt(""))
+
+	recovered, err := oracle.RecoverKeyFromIVReuse(o.Check, o.Encrypt(""))
+	require.NoError(t, err)
+	assert.Equal(t, key, recovered)
+
+	o.Key = []byte("PURPLE SUNRISE!!")
+	recovered, err = oracle.RecoverKeyFromIVReuse(o.Check, o.Encrypt(""))
+	require.NoError(t, err)
+	assert.Equal(t, o.Key, recovered)
+}
+
+func TestRecoverKeyFromIVReuse(t *testing.T) {
+	for _, allowUTF8 := range []bool{false, true} {
+		o := oracle.NewIVKeyOracle()
+		o.AllowUTF8 = allowUTF8
+
+		key, err := oracle.RecoverKeyFromIVReuse(o.Check, o.Encrypt(""))
+		require.NoError(t, err)
+		assert.Equal(t, o.Key, key)
+	}
+
+	t.Run("invalid ciphertext", func(t *testing.T) {
+		o := oracle.NewIVKeyOracle()
+		_, err := oracle.RecoverKeyFromIVReuse(o.Check, o.Encrypt("")[:16])
+		assert.Equal(t, oracle.ErrInvalidCiphertext, err)
+	})
+
+	t.Run("no leak", func(t *testing.T) {
+		o := oracle.NewIVKeyOracle()
+		_, err := oracle.RecoverKeyFromIVReuse(func([]byte) error { return nil }, o.Encrypt(""))
+		assert.Equal(t, oracle.ErrPlaintextAccepted, err)
+	})
+}
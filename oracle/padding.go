@@ -1,25 +1,47 @@
 package oracle
 
 import (
-	"bytes"
 	"encoding/base64"
-	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
 )
 
 // PaddingOracle implements a padding oracle.
+// It uses CBC by default, but other modes can be selected to compare how they
+// leak padding errors.
 type PaddingOracle struct {
-	key    []byte
 	IV     []byte
 	Secret []byte
+	Mode   BlockMode
+
+	key    []byte
+	scheme padding.Scheme
+	cache  cipherCache
 }
 
 // NewPaddingOracle creates a new padding oracle that can be attacked.
 // It also provides the encrypted string that should be decrypted.
 func NewPaddingOracle() (*PaddingOracle, []byte) {
+	return NewPaddingOracleWithMode(CBC)
+}
+
+// NewPaddingOracleWithMode creates a new padding oracle that uses the given
+// block mode.
+// It also provides the encrypted string that should be decrypted.
+func NewPaddingOracleWithMode(mode BlockMode) (*PaddingOracle, []byte) {
+	return NewPaddingOracleWithScheme(mode, padding.SchemePKCS7)
+}
+
+// NewPaddingOracleWithScheme creates a new padding oracle that uses the given
+// block mode and padding scheme.
+// With a scheme that validates in constant time, the oracle doesn't leak
+// through timing which padding byte is invalid, but it still tells whether
+// the padding is valid.
+// It also provides the encrypted string that should be decrypted.
+func NewPaddingOracleWithScheme(mode BlockMode, s padding.Scheme) (*PaddingOracle, []byte) {
 	rand.Seed(time.Now().UnixNano())
 	key := make([]byte, 16)
 	rand.Read(key)
@@ -41,94 +63,34 @@ func NewPaddingOracle() (*PaddingOracle, []byte) {
 	}
 
 	secretIndex := rand.Intn(10)
-	fmt.Printf("Secret index: %d\n", secretIndex)
 	secret, err := base64.StdEncoding.DecodeString(secrets[secretIndex])
 	if err != nil {
 		panic(err)
 	}
 
-	cbc := block.NewCBC(key, iv[:])
-	encrypted := cbc.Encrypt(secret)
-
-	return &PaddingOracle{
-		key:    key,
-		IV:     iv[:],
+	o := &PaddingOracle{
+		IV:     iv,
 		Secret: secret,
-	}, encrypted
-}
-
-// CheckPadding decrypts the given ciphertext and checks if padding is valid.
-func (o *PaddingOracle) CheckPadding(ciphertext []byte) (res bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			res = false
-		}
-	}()
-
-	cbc := block.NewCBC(o.key, o.IV)
-	cbc.Decrypt(ciphertext)
-	res = true
-	return
-}
-
-// DecryptWithPaddingOracle implements a padding oracle attack on CBC
-// encryption.
-func DecryptWithPaddingOracle(o *PaddingOracle, ciphertext []byte) error {
-	var decrypted []byte
-	blockCount := len(ciphertext) / 16
-
-	fmt.Printf("Trying to decrypt: %s\n", o.Secret)
-	fmt.Printf("Ciphertext contains %d blocks\n", blockCount)
-
-	// We need to prepend the IV block to decrypt the first block.
-	ciphertext = append(o.IV, ciphertext...)
-
-	for blockNumber := 0; blockNumber < blockCount; blockNumber++ {
-		decryptedBlock := decryptBlockWithPaddingOracle(o, ciphertext, blockNumber)
-		decrypted = append(decrypted, decryptedBlock...)
+		Mode:   mode,
+		key:    key,
+		scheme: s,
 	}
 
-	if !bytes.Equal(o.Secret, decrypted[:len(o.Secret)]) {
-		return fmt.Errorf("Invalid decryption:\nGot: %s\nWant: %s", decrypted, o.Secret)
+	encrypted, err := o.cipher().Encrypt(secret)
+	if err != nil {
+		panic(err)
 	}
 
-	return nil
+	return o, encrypted
 }
 
-func decryptBlockWithPaddingOracle(o *PaddingOracle, ciphertext []byte, blockNumber int) []byte {
-	fmt.Printf("Decrypting block %d\n", blockNumber)
-	decrypted := make([]byte, 16)
-
-	for i := 0; i < 16; i++ {
-		cipherPart := make([]byte, 16*2)
-		copy(cipherPart, ciphertext[16*blockNumber:16*(blockNumber+2)])
-
-		// Insert previously found bytes.
-		for j := 0; j < i; j++ {
-			cipherPart[len(cipherPart)-16-1-j] = cipherPart[len(cipherPart)-16-1-j] ^ decrypted[16-1-j] ^ byte(i+1)
-		}
-
-		found := false
-	guessLoop:
-		// Guess the current byte.
-		for g := decrypted[16-1-i] + 1; g <= 125; g++ {
-			encryptedGuess := make([]byte, len(cipherPart))
-			copy(encryptedGuess, cipherPart)
-			encryptedGuess[len(encryptedGuess)-16-1-i] = encryptedGuess[len(encryptedGuess)-16-1-i] ^ g ^ byte(i+1)
-
-			found = o.CheckPadding(encryptedGuess)
-			if found {
-				fmt.Printf("[%d] Found byte %c (%d)\n", i, g, g)
-				decrypted[16-1-i] = g
-				break guessLoop
-			}
-		}
-
-		if !found {
-			i -= 2
-		}
-	}
+// CheckPadding decrypts the given ciphertext and checks if padding is valid.
+// Modes that don't pad only reject ciphertexts they can't decrypt at all.
+func (o *PaddingOracle) CheckPadding(ciphertext []byte) bool {
+	_, err := o.cipher().Decrypt(ciphertext)
+	return err == nil
+}
 
-	fmt.Printf("Decrypted block %d: %s\n", blockNumber, decrypted)
-	return decrypted
+func (o *PaddingOracle) cipher() block.Cipher {
+	return o.cache.get(func() interface{} { return o.Mode.CipherWithPadding(o.key, o.IV, o.scheme) }, []byte{byte(o.Mode)}, o.IV).(block.Cipher)
 }
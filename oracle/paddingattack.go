@@ -0,0 +1,254 @@
+package oracle
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/t-bast/cryptopals/cipher/padding"
+)
+
+// Errors returned by padding oracle attacks.
+var (
+	// ErrNoValidPadding is returned when no guess gives a valid padding,
+	// which happens when the checker isn't a CBC padding oracle.
+	ErrNoValidPadding = errors.New("no guess gives a valid padding")
+	// ErrInvalidCiphertext is returned when a ciphertext isn't made of full
+	// blocks, with at least one block after the IV.
+	ErrInvalidCiphertext = errors.New("ciphertext must be at least two full blocks")
+	// ErrInvalidIV is returned when the IV isn't exactly one block long.
+	ErrInvalidIV = errors.New("IV length must equal block size")
+)
+
+// PaddingChecker tells whether a CBC ciphertext decrypts to a plaintext with
+// valid PKCS#7 padding.
+// It must be safe for concurrent use.
+type PaddingChecker func(ciphertext []byte) bool
+
+// PaddingAttackOptions configures a padding oracle attack.
+type PaddingAttackOptions struct {
+	// IV of the ciphertext.
+	// If nil, the first block of the ciphertext is used as IV and isn't
+	// decrypted.
+	IV []byte
+	// BlockSize of the cipher, 16 if 0.
+	BlockSize int
+	// Workers is the number of blocks decrypted in parallel, all of them if 0.
+	Workers int
+	// Progress is called every time a block has been decrypted, with the
+	// number of decrypted blocks.
+	// Calls are serialized.
+	Progress func(done, total int)
+}
+
+func (opts PaddingAttackOptions) blockSize() int {
+	if opts.BlockSize == 0 {
+		return 16
+	}
+
+	return opts.BlockSize
+}
+
+// DecryptWithPaddingOracle decrypts a CBC ciphertext with a padding oracle:
+// the plaintext is never revealed, but the checker tells whether a chosen
+// ciphertext has valid padding.
+// Each block is decrypted on its own, by crafting the block before it until
+// the padding is valid.
+// The checker only sees two-block ciphertexts: the crafted block followed by
+// the block being decrypted.
+// It returns the plaintext without its padding and the number of queries
+// made to the checker.
+func DecryptWithPaddingOracle(check PaddingChecker, ciphertext []byte, opts PaddingAttackOptions) ([]byte, int, error) {
+	blockSize := opts.blockSize()
+	if opts.IV != nil {
+		if len(opts.IV) != blockSize {
+			return nil, 0, ErrInvalidIV
+		}
+
+		ciphertext = append(opts.IV[:len(opts.IV):len(opts.IV)], ciphertext...)
+	}
+
+	if len(ciphertext)%blockSize != 0 || len(ciphertext) < 2*blockSize {
+		return nil, 0, ErrInvalidCiphertext
+	}
+
+	e := &paddingEngine{check: check, blockSize: blockSize}
+	blocks := len(ciphertext)/blockSize - 1
+	plaintext := make([]byte, blocks*blockSize)
+	errs := make([]error, blocks)
+
+	workers := opts.Workers
+	if workers <= 0 || workers > blocks {
+		workers = blocks
+	}
+
+	var mu sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				prev := ciphertext[i*blockSize : (i+1)*blockSize]
+				target := ciphertext[(i+1)*blockSize : (i+2)*blockSize]
+				intermediate, err := e.intermediate(prev, target)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				for j := range intermediate {
+					plaintext[i*blockSize+j] = intermediate[j] ^ prev[j]
+				}
+
+				if opts.Progress != nil {
+					mu.Lock()
+					done++
+					opts.Progress(done, blocks)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < blocks; i++ {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, e.count(), err
+		}
+	}
+
+	unpadded, err := padding.UnPKCS7(plaintext, blockSize)
+	return unpadded, e.count(), err
+}
+
+// paddingEngine recovers the block cipher decryption of ciphertext blocks
+// from a padding oracle, counting queries.
+type paddingEngine struct {
+	check     PaddingChecker
+	blockSize int
+	queries   int64
+}
+
+func (e *paddingEngine) query(ciphertext []byte) bool {
+	atomic.AddInt64(&e.queries, 1)
+	return e.check(ciphertext)
+}
+
+func (e *paddingEngine) count() int {
+	return int(atomic.LoadInt64(&e.queries))
+}
+
+// intermediate returns the block cipher decryption of target, before it is
+// XOR-ed with the previous block.
+// The crafted block starts as prev, which only changes how quickly valid
+// guesses are found.
+func (e *paddingEngine) intermediate(prev, target []byte) ([]byte, error) {
+	bs := e.blockSize
+	intermediate := make([]byte, bs)
+	crafted := make([]byte, 2*bs)
+	copy(crafted, prev)
+	copy(crafted[bs:], target)
+
+	for pos := bs - 1; pos >= 0; pos-- {
+		// Ask for pad bytes of value padLen in the known positions.
+		padLen := byte(bs - pos)
+		for j := pos + 1; j < bs; j++ {
+			crafted[j] = intermediate[j] ^ padLen
+		}
+
+		found := false
+		for g := 0; g < 256 && !found; g++ {
+			crafted[pos] = prev[pos] ^ byte(g)
+			if !e.query(crafted) {
+				continue
+			}
+
+			// On the last byte the padding can also be valid with a longer
+			// pad, like 0x02 0x02: changing the byte before it tells them
+			// apart.
+			found = true
+			if pos == bs-1 && pos > 0 {
+				crafted[pos-1] ^= 0xff
+				found = e.query(crafted)
+				crafted[pos-1] ^= 0xff
+			}
+		}
+
+		if !found {
+			return nil, ErrNoValidPadding
+		}
+
+		intermediate[pos] = crafted[pos] ^ padLen
+	}
+
+	return intermediate, nil
+}
+
+// ForgeOptions configures a padding oracle forgery.
+type ForgeOptions struct {
+	// BlockSize of the cipher, 16 if 0.
+	BlockSize int
+	// Progress is called every time a block has been forged, with the number
+	// of forged blocks.
+	Progress func(done, total int)
+}
+
+func (opts ForgeOptions) blockSize() int {
+	if opts.BlockSize == 0 {
+		return 16
+	}
+
+	return opts.BlockSize
+}
+
+// EncryptWithPaddingOracle forges a CBC ciphertext that decrypts to the
+// plaintext, with a padding oracle and without the key (CBC-R).
+// It works backwards from a random last block: the decryption of each block
+// is recovered like in DecryptWithPaddingOracle, and the block before it is
+// chosen to XOR it into the wanted plaintext block.
+// The first block ends up being the IV: when the checker uses a fixed IV
+// instead of reading it from the ciphertext, send the IV followed by the
+// ciphertext, whose first block then decrypts to garbage.
+// Blocks are forged one after the other, since each one depends on the next.
+// It returns the IV, the ciphertext and the number of queries made to the
+// checker.
+func EncryptWithPaddingOracle(check PaddingChecker, plaintext []byte, opts ForgeOptions) ([]byte, []byte, int, error) {
+	blockSize := opts.blockSize()
+	padded := padding.PKCS7(plaintext, blockSize)
+	blocks := len(padded) / blockSize
+
+	e := &paddingEngine{check: check, blockSize: blockSize}
+	forged := make([]byte, len(padded)+blockSize)
+	if _, err := rand.Read(forged[len(padded):]); err != nil {
+		return nil, nil, 0, err
+	}
+
+	for i := blocks - 1; i >= 0; i-- {
+		target := forged[(i+1)*blockSize : (i+2)*blockSize]
+		intermediate, err := e.intermediate(make([]byte, blockSize), target)
+		if err != nil {
+			return nil, nil, e.count(), err
+		}
+
+		for j := range intermediate {
+			forged[i*blockSize+j] = intermediate[j] ^ padded[i*blockSize+j]
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(blocks-i, blocks)
+		}
+	}
+
+	return forged[:blockSize], forged[blockSize:], e.count(), nil
+}
@@ -0,0 +1,117 @@
+package oracle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+func TestDecryptWithPaddingOracle(t *testing.T) {
+	iv := []byte("0123456789abcdef")
+	cbc := block.NewCBC([]byte("YELLOW SUBMARINE"), iv)
+	check := func(ciphertext []byte) bool {
+		_, err := cbc.Decrypt(ciphertext)
+		return err == nil
+	}
+
+	// Binary plaintexts with every byte value, and plaintexts ending like
+	// padding.
+	var binary []byte
+	for i := 0; i < 256; i++ {
+		binary = append(binary, byte(255-i))
+	}
+
+	plaintexts := [][]byte{
+		binary,
+		binary[:31],
+		[]byte("ends like padding\x02\x02"),
+		[]byte("ends like padding\x01"),
+		nil,
+	}
+
+	for _, plaintext := range plaintexts {
+		encrypted, err := cbc.Encrypt(plaintext)
+		require.NoError(t, err)
+
+		var progress []int
+		decrypted, queries, err := oracle.DecryptWithPaddingOracle(check, encrypted, oracle.PaddingAttackOptions{
+			IV:       iv,
+			Workers:  3,
+			Progress: func(done, total int) { progress = append(progress, done, total) },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, string(plaintext), string(decrypted))
+		assert.Less(t, queries, 256*len(encrypted))
+
+		blocks := len(encrypted) / 16
+		require.Len(t, progress, 2*blocks)
+		assert.Equal(t, []int{blocks, blocks}, progress[len(progress)-2:])
+	}
+
+	t.Run("IV in the ciphertext", func(t *testing.T) {
+		encrypted, err := cbc.Encrypt(binary)
+		require.NoError(t, err)
+
+		decrypted, _, err := oracle.DecryptWithPaddingOracle(check, append(iv, encrypted...), oracle.PaddingAttackOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, binary, decrypted)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := oracle.DecryptWithPaddingOracle(check, make([]byte, 20), oracle.PaddingAttackOptions{IV: iv})
+		assert.Equal(t, oracle.ErrInvalidCiphertext, err)
+
+		_, _, err = oracle.DecryptWithPaddingOracle(check, make([]byte, 32), oracle.PaddingAttackOptions{IV: iv[:8]})
+		assert.Equal(t, oracle.ErrInvalidIV, err)
+
+		_, _, err = oracle.DecryptWithPaddingOracle(func([]byte) bool { return false }, make([]byte, 32), oracle.PaddingAttackOptions{})
+		assert.Equal(t, oracle.ErrNoValidPadding, err)
+	})
+}
+
+func TestEncryptWithPaddingOracle(t *testing.T) {
+	iv := []byte("0123456789abcdef")
+	cbc := block.NewCBC([]byte("YELLOW SUBMARINE"), iv)
+
+	t.Run("IV in the ciphertext", func(t *testing.T) {
+		check := func(ciphertext []byte) bool {
+			_, err := block.NewCBC([]byte("YELLOW SUBMARINE"), ciphertext[:16]).Decrypt(ciphertext[16:])
+			return err == nil
+		}
+
+		for _, plaintext := range []string{"", "user=admin", "comment1=cooking%20MCs;admin=true;comment2=%20like%20a%20pound%20of%20bacon"} {
+			var progress []int
+			forgedIV, forged, queries, err := oracle.EncryptWithPaddingOracle(check, []byte(plaintext), oracle.ForgeOptions{
+				Progress: func(done, total int) { progress = append(progress, done, total) },
+			})
+			require.NoError(t, err)
+			assert.Len(t, forgedIV, 16)
+			assert.Less(t, queries, 256*(len(forged)+16))
+
+			decrypted, err := block.NewCBC([]byte("YELLOW SUBMARINE"), forgedIV).Decrypt(forged)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, string(decrypted))
+
+			blocks := len(forged) / 16
+			require.Len(t, progress, 2*blocks)
+			assert.Equal(t, []int{blocks, blocks}, progress[len(progress)-2:])
+		}
+	})
+
+	t.Run("fixed IV", func(t *testing.T) {
+		check := func(ciphertext []byte) bool {
+			_, err := cbc.Decrypt(ciphertext)
+			return err == nil
+		}
+
+		forgedIV, forged, _, err := oracle.EncryptWithPaddingOracle(check, []byte("role=admin"), oracle.ForgeOptions{})
+		require.NoError(t, err)
+
+		decrypted, err := cbc.Decrypt(append(forgedIV, forged...))
+		require.NoError(t, err)
+		assert.Equal(t, "role=admin", string(decrypted[16:]))
+	})
+}
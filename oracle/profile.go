@@ -0,0 +1,116 @@
+package oracle
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/t-bast/cryptopals/cipher/block"
+)
+
+// ErrNotBlockCipher is returned when profiling an oracle whose ciphertext
+// length grows one byte at a time, like stream ciphers.
+var ErrNotBlockCipher = errors.New("oracle doesn't encrypt in blocks")
+
+// maxBlockSize is the largest block size the profiler can detect.
+const maxBlockSize = 64
+
+// Encrypter is a black-box encryption oracle: it encrypts chosen plaintexts,
+// usually with an unknown prefix and suffix.
+// The Encrypt method of oracles that take bytes can be used directly.
+type Encrypter func(plaintext []byte) []byte
+
+// Profile describes how an oracle encrypts.
+type Profile struct {
+	BlockSize int
+	// ECB is true when identical plaintext blocks give identical ciphertext
+	// blocks.
+	ECB bool
+	// Deterministic is true when the oracle always encrypts the same
+	// plaintext the same way.
+	// PrefixLen and SuffixLen are only set for deterministic oracles.
+	Deterministic bool
+	// PrefixLen is the length of what the oracle adds before the plaintext.
+	PrefixLen int
+	// SuffixLen is the length of what the oracle adds after the plaintext.
+	SuffixLen int
+}
+
+// ProfileOracle finds out how the oracle encrypts from the length and content
+// of ciphertexts of chosen plaintexts.
+// The oracle must pad messages with at least one byte, like PKCS#7, and each
+// ciphertext block must only depend on the plaintext up to that block, like
+// ECB or CBC with a fixed IV.
+// It returns ErrNotBlockCipher if the oracle doesn't encrypt in blocks.
+func ProfileOracle(e Encrypter) (*Profile, error) {
+	// The block size is the gcd of the ciphertext lengths differences when the
+	// plaintext grows: it shows even when a random prefix changes the length.
+	base := e(nil)
+	blockSize := 0
+	grown := 0
+	for n := 1; n <= maxBlockSize; n++ {
+		diff := len(e(make([]byte, n))) - len(base)
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if diff != 0 && grown == 0 {
+			grown = n
+		}
+
+		blockSize = gcd(blockSize, diff)
+	}
+
+	if blockSize <= 1 {
+		return nil, ErrNotBlockCipher
+	}
+
+	p := &Profile{
+		BlockSize:     blockSize,
+		ECB:           block.DetectECB(e(make([]byte, 3*blockSize)), blockSize).Repeats > 0,
+		Deterministic: bytes.Equal(base, e(nil)),
+	}
+
+	if p.Deterministic {
+		// The plaintext filled the last block when it made the ciphertext
+		// grow by a block of padding.
+		p.PrefixLen = prefixLen(e, blockSize)
+		p.SuffixLen = len(base) - grown - p.PrefixLen
+	}
+
+	return p, nil
+}
+
+// prefixLen finds the length of the prefix of a deterministic oracle.
+func prefixLen(e Encrypter, blockSize int) int {
+	// The prefix ends in the first block that changes with the first byte of
+	// the plaintext.
+	first := 0
+	c1, c2 := e([]byte{0}), e([]byte{1})
+	for (first+1)*blockSize < len(c1) && bytes.Equal(c1[first*blockSize:(first+1)*blockSize], c2[first*blockSize:(first+1)*blockSize]) {
+		first++
+	}
+
+	// Then the plaintext grows until its last byte no longer changes that
+	// block: it has filled what the prefix left of it.
+	start, end := first*blockSize, (first+1)*blockSize
+	k := 1
+	for ; k <= blockSize; k++ {
+		probe := make([]byte, k)
+		c1 := e(probe)
+		probe[k-1] = 1
+		c2 := e(probe)
+		if bytes.Equal(c1[start:end], c2[start:end]) {
+			break
+		}
+	}
+
+	return end - k + 1
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
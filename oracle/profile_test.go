@@ -0,0 +1,95 @@
+package oracle_test
+
+import (
+	"crypto/des"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/cipher/stream"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+// withAffixes returns an oracle that encrypts the plaintext between the
+// prefix and the suffix.
+func withAffixes(c block.Cipher, prefix, suffix []byte) oracle.Encrypter {
+	return func(plaintext []byte) []byte {
+		message := append(append(append([]byte(nil), prefix...), plaintext...), suffix...)
+		encrypted, err := c.Encrypt(message)
+		if err != nil {
+			panic(err)
+		}
+
+		return encrypted
+	}
+}
+
+func TestProfileOracle(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+
+	t.Run("ECB", func(t *testing.T) {
+		for _, prefixLen := range []int{0, 1, 15, 16, 17, 40} {
+			for _, suffixLen := range []int{0, 5, 16, 33} {
+				e := withAffixes(block.NewECB(key), make([]byte, prefixLen), make([]byte, suffixLen))
+				p, err := oracle.ProfileOracle(e)
+				require.NoError(t, err)
+				assert.Equal(t, &oracle.Profile{
+					BlockSize:     16,
+					ECB:           true,
+					Deterministic: true,
+					PrefixLen:     prefixLen,
+					SuffixLen:     suffixLen,
+				}, p)
+			}
+		}
+	})
+
+	t.Run("CBC with a fixed IV", func(t *testing.T) {
+		e := withAffixes(block.NewCBC(key, make([]byte, 16)), []byte("comment1=cooking"), []byte(";comment2=%20like"))
+		p, err := oracle.ProfileOracle(e)
+		require.NoError(t, err)
+		assert.Equal(t, &oracle.Profile{BlockSize: 16, Deterministic: true, PrefixLen: 16, SuffixLen: 17}, p)
+	})
+
+	t.Run("CBC with a random IV", func(t *testing.T) {
+		p, err := oracle.ProfileOracle(func(plaintext []byte) []byte {
+			iv := make([]byte, 16)
+			rand.Read(iv)
+			return withAffixes(block.NewCBC(key, iv), nil, []byte("secret"))(plaintext)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &oracle.Profile{BlockSize: 16}, p)
+	})
+
+	t.Run("DES", func(t *testing.T) {
+		b, err := des.NewCipher(key[:8])
+		require.NoError(t, err)
+
+		p, err := oracle.ProfileOracle(func(plaintext []byte) []byte {
+			message := padding.PKCS7(append([]byte("pre"), plaintext...), 8)
+			block.NewECBEncrypter(b).CryptBlocks(message, message)
+			return message
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &oracle.Profile{BlockSize: 8, ECB: true, Deterministic: true, PrefixLen: 3}, p)
+	})
+
+	t.Run("ECB oracles", func(t *testing.T) {
+		p, err := oracle.ProfileOracle(oracle.NewECBOracle().Encrypt)
+		require.NoError(t, err)
+		assert.Equal(t, &oracle.Profile{BlockSize: 16, ECB: true, Deterministic: true, SuffixLen: 138}, p)
+
+		p, err = oracle.ProfileOracle(oracle.NewECBOracle2().Encrypt)
+		require.NoError(t, err)
+		assert.True(t, p.ECB)
+		assert.Equal(t, 138, p.SuffixLen)
+	})
+
+	t.Run("CTR", func(t *testing.T) {
+		_, err := oracle.ProfileOracle(stream.NewCTR(key, 0).Encrypt)
+		assert.Equal(t, oracle.ErrNotBlockCipher, err)
+	})
+}
@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returned when decoding profiles.
+var (
+	// ErrMalformedField is returned when a field isn't a single key=value
+	// pair.
+	ErrMalformedField = errors.New("malformed field")
+	// ErrInvalidEscape is returned when a percent isn't followed by two
+	// hexadecimal digits.
+	ErrInvalidEscape = errors.New("invalid escape sequence")
+	// ErrDuplicateKey is returned when a key appears several times and
+	// duplicates are rejected.
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrInvalidUID is returned when the uid isn't a canonical decimal
+	// integer.
+	ErrInvalidUID = errors.New("invalid uid")
+)
+
+// escaped are the characters that are percent-escaped in keys and values.
+const escaped = "%&="
+
+func escape(s string) string {
+	if !strings.ContainsAny(s, escaped) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(escaped, s[i]) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", s[i])
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidEscape, s[i:])
+		}
+
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidEscape, s[i:i+3])
+		}
+
+		b.WriteByte(byte(c))
+		i += 2
+	}
+
+	return b.String(), nil
+}
+
+// DuplicatePolicy tells how to decode keys that appear several times.
+type DuplicatePolicy int
+
+// Duplicate key policies.
+const (
+	// RejectDuplicates returns ErrDuplicateKey.
+	RejectDuplicates DuplicatePolicy = iota
+	// FirstWins keeps the first value.
+	FirstWins
+	// LastWins keeps the last value, where the first one was.
+	LastWins
+)
+
+// Decoder decodes user profiles.
+type Decoder struct {
+	Duplicates DuplicatePolicy
+}
+
+// Decode decodes a user profile, rejecting duplicate keys.
+func Decode(s string) (*UserProfile, error) {
+	return Decoder{}.Decode(s)
+}
+
+// Decode decodes a user profile encoded by its String method.
+// Missing fields are left empty and unknown ones are kept as extra fields.
+func (d Decoder) Decode(s string) (*UserProfile, error) {
+	var l Layout
+	seen := make(map[string]int)
+	for _, part := range strings.Split(s, "&") {
+		if strings.Count(part, "=") != 1 {
+			return nil, fmt.Errorf("%w: %q", ErrMalformedField, part)
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		key, err := unescape(kv[0])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := unescape(kv[1])
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := seen[key]
+		if !ok {
+			seen[key] = len(l)
+			l = append(l, Field{Key: key, Value: value})
+			continue
+		}
+
+		switch d.Duplicates {
+		case FirstWins:
+		case LastWins:
+			l[i].Value = value
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+		}
+	}
+
+	p := &UserProfile{}
+	for _, f := range l {
+		switch f.Key {
+		case "email":
+			p.Email = f.Value
+		case "uid":
+			uid, err := strconv.Atoi(f.Value)
+			if err != nil || strconv.Itoa(uid) != f.Value {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidUID, f.Value)
+			}
+
+			p.UID = uid
+		case "role":
+			p.Role = f.Value
+		default:
+			p.Extra = append(p.Extra, f)
+		}
+	}
+
+	return p, nil
+}
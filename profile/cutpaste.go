@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/oracle"
+)
+
+// Errors returned by cut-and-paste attacks.
+var (
+	// ErrUnknownField is returned when the controlled or target field isn't
+	// in the layout.
+	ErrUnknownField = errors.New("field isn't in the layout")
+	// ErrLayoutMismatch is returned when the encrypted profiles don't have
+	// the expected layout.
+	ErrLayoutMismatch = errors.New("ciphertexts don't match the layout")
+	// ErrNoAlignment is returned when the target value never starts on a
+	// block boundary, because it's before the controlled field.
+	ErrNoAlignment = errors.New("target value can't be aligned on a block")
+	// ErrUnsafeValue is returned when the encoder alters the padded target
+	// value, for example because it contains separators.
+	ErrUnsafeValue = errors.New("encoder alters the target value")
+)
+
+// filler is used to align fields on block boundaries.
+const filler = "A"
+
+// CutAndPaste forges ECB ciphertexts of profiles encoded as key=value.
+// Blocks of ciphertexts for well-chosen values of the controlled field are
+// spliced together.
+type CutAndPaste struct {
+	// Encrypt encrypts the profile whose controlled field has the given
+	// value.
+	// It must encrypt with ECB and pad messages with at least one byte.
+	Encrypt func(value string) []byte
+	// Layout of the encrypted profiles.
+	// The value of the controlled field is ignored.
+	Layout Layout
+	// Controlled is the key of the field whose value is given to Encrypt.
+	Controlled string
+	// Padding scheme of the encrypted profiles, PKCS#7 if nil.
+	Padding padding.Scheme
+}
+
+func (a CutAndPaste) padding() padding.Scheme {
+	if a.Padding == nil {
+		return padding.SchemePKCS7
+	}
+
+	return a.Padding
+}
+
+// Forge returns the ciphertext of a profile whose target field has the given
+// value.
+// The fields before the target are kept, the ones after it are dropped: its
+// value ends the forged profile.
+// The block size and layout are checked against the ciphertext lengths.
+func (a CutAndPaste) Forge(target, value string) ([]byte, error) {
+	c, t := a.Layout.index(a.Controlled), a.Layout.index(target)
+	if c < 0 || t < 0 {
+		return nil, ErrUnknownField
+	}
+
+	p, err := oracle.ProfileOracle(func(plaintext []byte) []byte { return a.Encrypt(string(plaintext)) })
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.ECB {
+		return nil, oracle.ErrNotECB
+	}
+
+	bs := p.BlockSize
+	empty := a.withControlled("")
+	if !p.Deterministic || p.PrefixLen != empty.valueOffset(c) || p.PrefixLen+p.SuffixLen != len(empty.String()) {
+		return nil, ErrLayoutMismatch
+	}
+
+	// The target value must start on a block boundary, so that the blocks
+	// before it can be followed by our own.
+	// The controlled value only moves it when it comes first.
+	head := -1
+	for n := 0; n < bs; n++ {
+		if a.withControlled(fill(n)).valueOffset(t)%bs == 0 {
+			head = n
+			break
+		}
+	}
+
+	if head < 0 {
+		return nil, ErrNoAlignment
+	}
+
+	// The padded target value is encrypted on its own blocks at the start of
+	// the controlled value.
+	// It's repeated to check that the encoder left it untouched.
+	padded := a.padding().Pad([]byte(value), bs)
+	start := p.PrefixLen + (bs-p.PrefixLen%bs)%bs
+	valueCiphertext := a.Encrypt(fill(start-p.PrefixLen) + string(padded) + string(padded))
+	if len(valueCiphertext) < start+2*len(padded) {
+		return nil, ErrUnsafeValue
+	}
+
+	valueBlocks := valueCiphertext[start : start+len(padded)]
+	if !bytes.Equal(valueBlocks, valueCiphertext[start+len(padded):start+2*len(padded)]) {
+		return nil, ErrUnsafeValue
+	}
+
+	end := a.withControlled(fill(head)).valueOffset(t)
+
+	var forged []byte
+	forged = append(forged, a.Encrypt(fill(head))[:end]...)
+	forged = append(forged, valueBlocks...)
+	return forged, nil
+}
+
+// withControlled returns a copy of the layout where the controlled field has
+// the given value.
+func (a CutAndPaste) withControlled(value string) Layout {
+	l := make(Layout, len(a.Layout))
+	copy(l, a.Layout)
+	l[l.index(a.Controlled)].Value = value
+	return l
+}
+
+func fill(n int) string {
+	return strings.Repeat(filler, n)
+}
+
+// index returns the position of the field with the given key, or -1.
+func (l Layout) index(key string) int {
+	for i, f := range l {
+		if f.Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// valueOffset returns the offset of the i-th field's value in the encoded
+// layout.
+func (l Layout) valueOffset(i int) int {
+	offset := 0
+	for _, f := range l[:i] {
+		offset += len(escape(f.Key)) + len(escape(f.Value)) + 2
+	}
+
+	return offset + len(escape(l[i].Key)) + 1
+}
@@ -0,0 +1,147 @@
+package profile_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/cipher/block"
+	"github.com/t-bast/cryptopals/cipher/padding"
+	"github.com/t-bast/cryptopals/oracle"
+	"github.com/t-bast/cryptopals/profile"
+)
+
+// keyValueOracle encrypts the layout with ECB, with separators stripped from
+// the controlled value.
+func keyValueOracle(b cipher.Block, layout profile.Layout, controlled string) func(string) []byte {
+	return func(value string) []byte {
+		sanitized := strings.NewReplacer("&", "", "=", "").Replace(value)
+		l := make(profile.Layout, len(layout))
+		for i, f := range layout {
+			l[i] = f
+			if f.Key == controlled {
+				l[i].Value = sanitized
+			}
+		}
+
+		message := padding.PKCS7([]byte(l.String()), b.BlockSize())
+		block.NewECBEncrypter(b).CryptBlocks(message, message)
+		return message
+	}
+}
+
+func decryptKeyValue(t *testing.T, b cipher.Block, ciphertext []byte) map[string]string {
+	decrypted := make([]byte, len(ciphertext))
+	block.NewECBDecrypter(b).CryptBlocks(decrypted, ciphertext)
+	unpadded, err := padding.UnPKCS7(decrypted, b.BlockSize())
+	require.NoError(t, err)
+
+	fields := make(map[string]string)
+	for _, part := range strings.Split(string(unpadded), "&") {
+		kv := strings.SplitN(part, "=", 2)
+		require.Len(t, kv, 2)
+		fields[kv[0]] = kv[1]
+	}
+
+	return fields
+}
+
+func TestCutAndPaste(t *testing.T) {
+	aesBlock, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	require.NoError(t, err)
+	desBlock, err := des.NewCipher([]byte("SUBMARIN"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		b        cipher.Block
+		layout   profile.Layout
+		value    string
+		expected map[string]string
+	}{{
+		"long uid",
+		aesBlock,
+		profile.Layout{{"email", ""}, {"uid", "1234567"}, {"role", "user"}},
+		"admin",
+		map[string]string{"email": "AAAAAAAA", "uid": "1234567", "role": "admin"},
+	}, {
+		"fields before the controlled field",
+		aesBlock,
+		profile.Layout{{"uid", "42"}, {"name", "bob"}, {"email", ""}, {"role", "guest"}},
+		"superuser",
+		map[string]string{"uid": "42", "name": "bob", "email": "AAAA", "role": "superuser"},
+	}, {
+		"fields after the target are dropped",
+		aesBlock,
+		profile.Layout{{"email", ""}, {"role", "user"}, {"uid", "10"}},
+		"admin",
+		map[string]string{"email": "AAAA", "role": "admin"},
+	}, {
+		"value longer than a block",
+		aesBlock,
+		profile.Layout{{"email", ""}, {"uid", "10"}, {"role", "user"}},
+		"administrator of everything",
+		map[string]string{"email": "AAAAAAAAAAAAA", "uid": "10", "role": "administrator of everything"},
+	}, {
+		"DES",
+		desBlock,
+		profile.Layout{{"email", ""}, {"uid", "10"}, {"role", "user"}},
+		"admin",
+		map[string]string{"email": "AAAAA", "uid": "10", "role": "admin"},
+	}}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			a := profile.CutAndPaste{
+				Encrypt:    keyValueOracle(tt.b, tt.layout, "email"),
+				Layout:     tt.layout,
+				Controlled: "email",
+			}
+
+			forged, err := a.Forge("role", tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, decryptKeyValue(t, tt.b, forged))
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		layout := profile.Layout{{"email", ""}, {"uid", "10"}, {"role", "user"}}
+		a := profile.CutAndPaste{
+			Encrypt:    keyValueOracle(aesBlock, layout, "email"),
+			Layout:     layout,
+			Controlled: "email",
+		}
+
+		_, err := a.Forge("admin", "true")
+		assert.Equal(t, profile.ErrUnknownField, err)
+
+		_, err = a.Forge("role", "ad=min")
+		assert.Equal(t, profile.ErrUnsafeValue, err)
+
+		a.Layout = profile.Layout{{"email", ""}, {"uid", "1000"}, {"role", "user"}}
+		_, err = a.Forge("role", "admin")
+		assert.Equal(t, profile.ErrLayoutMismatch, err)
+
+		// The target value can't move when it comes first.
+		layout = profile.Layout{{"role", "user"}, {"email", ""}}
+		a = profile.CutAndPaste{
+			Encrypt:    keyValueOracle(aesBlock, layout, "email"),
+			Layout:     layout,
+			Controlled: "email",
+		}
+		_, err = a.Forge("role", "admin")
+		assert.Equal(t, profile.ErrNoAlignment, err)
+
+		a.Encrypt = func(value string) []byte {
+			encrypted, err := block.NewCBC([]byte("YELLOW SUBMARINE"), make([]byte, 16)).Encrypt([]byte(value))
+			require.NoError(t, err)
+			return encrypted
+		}
+		_, err = a.Forge("role", "admin")
+		assert.Equal(t, oracle.ErrNotECB, err)
+	})
+}
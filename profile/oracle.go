@@ -9,7 +9,7 @@ import (
 
 // UserProfileOracle encrypts user profiles.
 type UserProfileOracle struct {
-	key []byte
+	ecb *block.ECB
 }
 
 // NewUserProfileOracle creates a fixed key for encryption.
@@ -19,33 +19,43 @@ func NewUserProfileOracle() *UserProfileOracle {
 	rand.Read(key)
 
 	return &UserProfileOracle{
-		key: key,
+		ecb: block.NewECB(key),
 	}
 }
 
 // Encrypt a user profile.
 func (o *UserProfileOracle) Encrypt(p *UserProfile) []byte {
-	ecb := block.NewECB(o.key)
-	return ecb.Encrypt([]byte(p.String()))
+	encrypted, err := o.ecb.Encrypt([]byte(p.String()))
+	if err != nil {
+		panic(err)
+	}
+
+	return encrypted
 }
 
 // Decrypt a user profile.
-func (o *UserProfileOracle) Decrypt(encrypted []byte) *UserProfile {
-	ecb := block.NewECB(o.key)
-	decrypted := ecb.Decrypt(encrypted)
-	return Unstring(string(decrypted))
+// It returns an error if the ciphertext can't be decrypted or decoded.
+func (o *UserProfileOracle) Decrypt(encrypted []byte) (*UserProfile, error) {
+	decrypted, err := o.ecb.Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(string(decrypted))
 }
 
 // CreateAdminProfile creates an admin profile by exploiting flaws in ECB.
-func CreateAdminProfile(o *UserProfileOracle) *UserProfile {
-	// Find the encrypted block for "admin\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B".
-	e1 := o.Encrypt(NewUserProfile("AAAAAAAAAAadmin\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B\x0B"))
+func CreateAdminProfile(o *UserProfileOracle) (*UserProfile, error) {
+	a := CutAndPaste{
+		Encrypt:    func(email string) []byte { return o.Encrypt(NewUserProfile(email)) },
+		Layout:     NewUserProfile("").Layout(),
+		Controlled: "email",
+	}
 
-	// Replace it in a valid ciphertext where the "user" part is isolated at the end.
-	e2 := o.Encrypt(NewUserProfile("pwned@bar.com"))
-	e := make([]byte, len(e2))
-	copy(e, e2)
-	copy(e[32:], e1[16:32])
+	forged, err := a.Forge("role", "admin")
+	if err != nil {
+		return nil, err
+	}
 
-	return o.Decrypt(e)
+	return o.Decrypt(forged)
 }
@@ -1,7 +1,6 @@
 package profile
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 )
@@ -11,39 +10,53 @@ type UserProfile struct {
 	Email string
 	UID   int
 	Role  string
+	// Extra fields are encoded after the others, in order.
+	// Their keys must be unique and differ from the other fields' keys for
+	// the profile to decode to itself.
+	Extra []Field
 }
 
 // NewUserProfile creates a new user profile with default role.
 func NewUserProfile(email string) *UserProfile {
-	sanitized := strings.Replace(strings.Replace(email, "&", "", -1), "=", "", -1)
 	return &UserProfile{
-		Email: sanitized,
+		Email: email,
 		UID:   10,
 		Role:  "user",
 	}
 }
 
+// Layout of the user profile's fields, in the order they're encoded.
+func (p *UserProfile) Layout() Layout {
+	l := Layout{
+		{Key: "email", Value: p.Email},
+		{Key: "uid", Value: strconv.Itoa(p.UID)},
+		{Key: "role", Value: p.Role},
+	}
+
+	return append(l, p.Extra...)
+}
+
 // String version of the user profile.
 func (p *UserProfile) String() string {
-	return fmt.Sprintf("email=%s&uid=%d&role=%s", p.Email, p.UID, p.Role)
+	return p.Layout().String()
 }
 
-// Unstring decodes a stringified user profile.
-func Unstring(userProfile string) *UserProfile {
-	p := &UserProfile{}
-	parts := strings.Split(userProfile, "&")
-	for _, part := range parts {
-		kv := strings.Split(part, "=")
-		switch kv[0] {
-		case "email":
-			p.Email = kv[1]
-		case "uid":
-			uid, _ := strconv.ParseInt(kv[1], 10, 32)
-			p.UID = int(uid)
-		case "role":
-			p.Role = kv[1]
-		}
+// Field of a profile encoded as key=value.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Layout of a profile: its fields in the order they're encoded.
+type Layout []Field
+
+// String encodes the fields as key=value pairs separated by "&".
+// Keys and values are percent-escaped so that they can't inject fields.
+func (l Layout) String() string {
+	parts := make([]string, len(l))
+	for i, f := range l {
+		parts[i] = escape(f.Key) + "=" + escape(f.Value)
 	}
 
-	return p
+	return strings.Join(parts, "&")
 }
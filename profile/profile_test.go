@@ -4,24 +4,122 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/t-bast/cryptopals/profile"
 )
 
 func TestUserProfile(t *testing.T) {
-	t.Run("string and unstring", func(t *testing.T) {
+	t.Run("encode and decode", func(t *testing.T) {
 		p := profile.NewUserProfile("foo@bar.com")
 		ps := p.String()
 		assert.Equal(t, "email=foo@bar.com&uid=10&role=user", ps)
 
-		pp := profile.Unstring(ps)
-		assert.Equal(t, p.Email, pp.Email)
-		assert.Equal(t, p.UID, pp.UID)
-		assert.Equal(t, p.Role, pp.Role)
+		pp, err := profile.Decode(ps)
+		require.NoError(t, err)
+		assert.Equal(t, p, pp)
 	})
 
 	t.Run("escapes characters", func(t *testing.T) {
-		p := profile.NewUserProfile("foo@bar.com&role=admin")
-		assert.Equal(t, "foo@bar.comroleadmin", p.Email)
-		assert.Equal(t, "email=foo@bar.comroleadmin&uid=10&role=user", p.String())
+		p := profile.NewUserProfile("foo@bar.com&role=admin%")
+		assert.Equal(t, "foo@bar.com&role=admin%", p.Email)
+		assert.Equal(t, "email=foo@bar.com%26role%3Dadmin%25&uid=10&role=user", p.String())
+
+		pp, err := profile.Decode(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, pp)
+	})
+
+	t.Run("extra fields", func(t *testing.T) {
+		p := profile.NewUserProfile("foo@bar.com")
+		p.Extra = []profile.Field{{Key: "name", Value: "Bob"}, {Key: "a=b", Value: ""}}
+		assert.Equal(t, "email=foo@bar.com&uid=10&role=user&name=Bob&a%3Db=", p.String())
+
+		pp, err := profile.Decode(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, pp)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		p, err := profile.Decode("role=admin")
+		require.NoError(t, err)
+		assert.Equal(t, &profile.UserProfile{Role: "admin"}, p)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		testCases := []struct {
+			encoded  string
+			expected error
+		}{
+			{"", profile.ErrMalformedField},
+			{"email=foo&uid", profile.ErrMalformedField},
+			{"email=foo&&uid=10", profile.ErrMalformedField},
+			{"email=a=b", profile.ErrMalformedField},
+			{"email=foo%2", profile.ErrInvalidEscape},
+			{"email=foo%zz", profile.ErrInvalidEscape},
+			{"em%ail=foo", profile.ErrInvalidEscape},
+			{"uid=ten", profile.ErrInvalidUID},
+			{"uid=010", profile.ErrInvalidUID},
+			{"uid=+10", profile.ErrInvalidUID},
+			{"uid=99999999999999999999", profile.ErrInvalidUID},
+			{"role=user&role=admin", profile.ErrDuplicateKey},
+			{"role=user&r%6Fle=admin", profile.ErrDuplicateKey},
+		}
+
+		for _, tt := range testCases {
+			_, err := profile.Decode(tt.encoded)
+			assert.ErrorIs(t, err, tt.expected, tt.encoded)
+		}
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		encoded := "email=foo&role=user&x=1&role=admin&x=2"
+
+		p, err := profile.Decoder{Duplicates: profile.FirstWins}.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, "user", p.Role)
+		assert.Equal(t, []profile.Field{{Key: "x", Value: "1"}}, p.Extra)
+
+		p, err = profile.Decoder{Duplicates: profile.LastWins}.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", p.Role)
+		assert.Equal(t, []profile.Field{{Key: "x", Value: "2"}}, p.Extra)
+	})
+}
+
+func FuzzUserProfile(f *testing.F) {
+	f.Add("foo@bar.com", 10, "user", "name", "Bob")
+	f.Add("foo@bar.com&role=admin", -1, "", "", "")
+	f.Add("%41%", 0, "a=b&c", "uid", "%")
+
+	f.Fuzz(func(t *testing.T, email string, uid int, role, key, value string) {
+		p := &profile.UserProfile{Email: email, UID: uid, Role: role}
+		if key != "email" && key != "uid" && key != "role" {
+			p.Extra = []profile.Field{{Key: key, Value: value}}
+		}
+
+		decoded, err := profile.Decode(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, decoded)
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add("email=foo@bar.com&uid=10&role=user")
+	f.Add("email=a%26b&x=%3D")
+	f.Add("uid=1&uid=2")
+
+	f.Fuzz(func(t *testing.T, encoded string) {
+		// Decoding never panics, and what it decodes encodes back to the
+		// same profile.
+		for _, d := range []profile.Decoder{{}, {Duplicates: profile.FirstWins}, {Duplicates: profile.LastWins}} {
+			p, err := d.Decode(encoded)
+			if err != nil {
+				continue
+			}
+
+			decoded, err := profile.Decode(p.String())
+			require.NoError(t, err)
+			assert.Equal(t, p, decoded)
+		}
 	})
 }
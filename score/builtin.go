@@ -0,0 +1,51 @@
+package score
+
+import (
+	"bytes"
+	"embed"
+	"sync"
+)
+
+// builtinModels are trained on the public-domain corpora in the corpus
+// directory.
+//
+//go:embed models/*.model
+var builtinModels embed.FS
+
+var (
+	englishOnce  sync.Once
+	englishModel *Model
+	frenchOnce   sync.Once
+	frenchModel  *Model
+)
+
+// English returns the built-in english language model.
+func English() *Model {
+	englishOnce.Do(func() { englishModel = loadBuiltin("en") })
+	return englishModel
+}
+
+// French returns the built-in french language model.
+func French() *Model {
+	frenchOnce.Do(func() { frenchModel = loadBuiltin("fr") })
+	return frenchModel
+}
+
+// Languages returns all the built-in language models.
+func Languages() []*Model {
+	return []*Model{English(), French()}
+}
+
+func loadBuiltin(language string) *Model {
+	data, err := builtinModels.ReadFile("models/" + language + ".model")
+	if err != nil {
+		panic(err)
+	}
+
+	m, err := ReadModel(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
@@ -0,0 +1,93 @@
+package score
+
+// englishCharFrequency is the expected distribution of characters in english
+// text, as fractions.
+// Letters share 80% of the characters following letterFrequency and spaces
+// take most of the rest.
+var englishCharFrequency = func() map[byte]float64 {
+	freqs := make(map[byte]float64)
+	for b, f := range letterFrequency {
+		if b != ' ' {
+			freqs[b] = 0.8 * float64(f) / 100
+		}
+	}
+
+	freqs[' '] = 0.17
+	return freqs
+}()
+
+// invalidFrequency is the expected frequency of non-printable characters.
+// It isn't zero so that chi-squared statistics stay finite.
+const invalidFrequency = 0.0001
+
+// ChiSquared scores candidates with Pearson's chi-squared test between their
+// character distribution and an expected one.
+// The statistic is normalized by the candidate length and negated, so that
+// candidates of different lengths can be compared and higher is better.
+type ChiSquared struct {
+	expected map[byte]float64
+	other    float64
+}
+
+// NewChiSquared creates a chi-squared scorer from the expected frequencies of
+// characters, as fractions.
+// Upper-case letters are counted as lower-case ones.
+// Printable characters that aren't listed share the remaining frequency and
+// non-printable characters are expected to be very rare.
+func NewChiSquared(frequencies map[byte]float64) *ChiSquared {
+	expected := make(map[byte]float64, len(frequencies))
+	total := 0.0
+	for b, f := range frequencies {
+		expected[b] = f
+		total += f
+	}
+
+	other := 1 - total - invalidFrequency
+	if other < invalidFrequency {
+		other = invalidFrequency
+	}
+
+	return &ChiSquared{expected: expected, other: other}
+}
+
+// NewEnglishChiSquared creates a chi-squared scorer for english text.
+func NewEnglishChiSquared() *ChiSquared {
+	return NewChiSquared(englishCharFrequency)
+}
+
+// Score a candidate.
+func (c *ChiSquared) Score(candidate []byte) float64 {
+	if len(candidate) == 0 {
+		return 0
+	}
+
+	counts := make(map[byte]int)
+	other := 0
+	invalid := 0
+	for _, b := range candidate {
+		b = toLower(b)
+		if _, ok := c.expected[b]; ok {
+			counts[b]++
+		} else if isPrintable(b) {
+			other++
+		} else {
+			invalid++
+		}
+	}
+
+	n := float64(len(candidate))
+	chi2 := 0.0
+	for b, f := range c.expected {
+		chi2 += chi2Term(float64(counts[b]), f*n)
+	}
+
+	chi2 += chi2Term(float64(other), c.other*n)
+	chi2 += chi2Term(float64(invalid), invalidFrequency*n)
+
+	return -chi2 / n
+}
+
+func chi2Term(observed, expected float64) float64 {
+	d := observed - expected
+	return d * d / expected
+}
@@ -0,0 +1,34 @@
+//go:build ignore
+
+// This program trains the built-in language models from the corpora.
+// Run it with go generate.
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/t-bast/cryptopals/score"
+)
+
+// order of the built-in models.
+const order = 3
+
+func main() {
+	for _, language := range []string{"en", "fr"} {
+		f, err := os.Open(filepath.Join("corpus", language+".txt"))
+		if err != nil {
+			panic(err)
+		}
+
+		m, err := score.Train(language, f, order)
+		f.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		if err := m.Save(filepath.Join("models", language+".model")); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package score
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run gen_models.go
+
+// modelHeader is the first line of serialized models.
+const modelHeader = "# cryptopals language model v1"
+
+// alphabetSize is the number of symbols a model can see (all bytes).
+const alphabetSize = 256
+
+// ErrInvalidModel is returned when reading a malformed serialized model.
+var ErrInvalidModel = errors.New("invalid language model")
+
+// Model is a byte n-gram language model trained on a text corpus.
+// Bytes are counted as-is: case is part of the model, which helps telling
+// apart keys that only differ by the case bit, and UTF-8 encoded accents are
+// modeled as byte sequences.
+// A model is a Scorer: it scores candidates with the average log-likelihood
+// of their bytes, which doesn't depend on the candidate length.
+type Model struct {
+	Language string
+
+	order  int
+	counts map[string]int
+	totals []int
+}
+
+// NewModel creates an empty model for n-grams up to the given order.
+func NewModel(language string, order int) *Model {
+	if order < 1 {
+		order = 1
+	}
+
+	return &Model{
+		Language: language,
+		order:    order,
+		counts:   make(map[string]int),
+		totals:   make([]int, order+1),
+	}
+}
+
+// Train creates a model from the text corpus read from r.
+func Train(language string, r io.Reader, order int) (*Model, error) {
+	corpus, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewModel(language, order)
+	m.Add(corpus)
+
+	return m, nil
+}
+
+// Order returns the length of the longest n-grams of the model.
+func (m *Model) Order() int {
+	return m.order
+}
+
+// Add trains the model on some more text.
+func (m *Model) Add(text []byte) {
+	for i := range text {
+		for n := 1; n <= m.order && i+n <= len(text); n++ {
+			m.counts[string(text[i:i+n])]++
+			m.totals[n]++
+		}
+	}
+}
+
+// WithOrder returns a view of the model that only uses n-grams up to the
+// given order.
+// A unigram view is useful to score isolated characters, for example a
+// column of bytes encrypted with the same key byte.
+func (m *Model) WithOrder(order int) *Model {
+	if order < 1 {
+		order = 1
+	}
+
+	if order > m.order {
+		order = m.order
+	}
+
+	view := *m
+	view.order = order
+	return &view
+}
+
+// Score a candidate.
+func (m *Model) Score(candidate []byte) float64 {
+	if len(candidate) == 0 {
+		return math.Log(1.0 / alphabetSize)
+	}
+
+	total := 0.0
+	for i := range candidate {
+		total += math.Log(m.probability(candidate, i))
+	}
+
+	return total / float64(len(candidate))
+}
+
+// LogProbability returns the log-probability of the i-th byte of a text given
+// the bytes before it.
+func (m *Model) LogProbability(text []byte, i int) float64 {
+	return math.Log(m.probability(text, i))
+}
+
+// probability of the i-th byte of a text given the bytes before it.
+// Probabilities of every order are interpolated, giving more weight to longer
+// contexts.
+func (m *Model) probability(text []byte, i int) float64 {
+	p := 0.0
+	weights := 0.0
+	weight := 1.0
+	for n := 1; n <= m.order && n <= i+1; n++ {
+		var pn float64
+		if n == 1 {
+			pn = float64(m.counts[string(text[i])]+1) / float64(m.totals[1]+alphabetSize)
+		} else if context := m.counts[string(text[i-n+1:i])]; context > 0 {
+			pn = float64(m.counts[string(text[i-n+1:i+1])]) / float64(context)
+		}
+
+		p += weight * pn
+		weights += weight
+		weight *= 2
+	}
+
+	return p / weights
+}
+
+// WriteTo serializes the model to w.
+// N-grams are hex-encoded, one per line with their count, in a stable order.
+func (m *Model) WriteTo(w io.Writer) (int64, error) {
+	ngrams := make([]string, 0, len(m.counts))
+	for ngram := range m.counts {
+		if len(ngram) <= m.order {
+			ngrams = append(ngrams, ngram)
+		}
+	}
+
+	sort.Slice(ngrams, func(i, j int) bool {
+		if len(ngrams[i]) != len(ngrams[j]) {
+			return len(ngrams[i]) < len(ngrams[j])
+		}
+
+		return ngrams[i] < ngrams[j]
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, modelHeader)
+	fmt.Fprintf(&buf, "language %s\n", m.Language)
+	fmt.Fprintf(&buf, "order %d\n", m.order)
+	for _, ngram := range ngrams {
+		fmt.Fprintf(&buf, "%s %d\n", hex.EncodeToString([]byte(ngram)), m.counts[ngram])
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadModel reads a model serialized with WriteTo.
+func ReadModel(r io.Reader) (*Model, error) {
+	scanner := bufio.NewScanner(r)
+	readLine := func(prefix string) (string, error) {
+		if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), prefix) {
+			return "", ErrInvalidModel
+		}
+
+		return strings.TrimPrefix(scanner.Text(), prefix), nil
+	}
+
+	if _, err := readLine(modelHeader); err != nil {
+		return nil, err
+	}
+
+	language, err := readLine("language ")
+	if err != nil {
+		return nil, err
+	}
+
+	orderLine, err := readLine("order ")
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := strconv.Atoi(orderLine)
+	if err != nil || order < 1 {
+		return nil, ErrInvalidModel
+	}
+
+	m := NewModel(language, order)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, ErrInvalidModel
+		}
+
+		ngram, err := hex.DecodeString(fields[0])
+		if err != nil || len(ngram) == 0 || len(ngram) > order {
+			return nil, ErrInvalidModel
+		}
+
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count < 0 {
+			return nil, ErrInvalidModel
+		}
+
+		m.counts[string(ngram)] = count
+		m.totals[len(ngram)] += count
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Save serializes the model to a file.
+func (m *Model) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadModel reads a model from a file created with Save.
+func LoadModel(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return ReadModel(f)
+}
+
+// DetectLanguage returns the model under which the text is the most likely,
+// with its score.
+// It returns nil if no model is given.
+func DetectLanguage(text []byte, models ...*Model) (*Model, float64) {
+	var best *Model
+	bestScore := math.Inf(-1)
+	for _, m := range models {
+		if s := m.Score(text); s > bestScore {
+			best = m
+			bestScore = s
+		}
+	}
+
+	return best, bestScore
+}
@@ -0,0 +1,55 @@
+package score_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func TestModel(t *testing.T) {
+	t.Run("train and serialize", func(t *testing.T) {
+		m, err := score.Train("test", strings.NewReader("the cat and the hat"), 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, m.Order())
+
+		var buf bytes.Buffer
+		_, err = m.WriteTo(&buf)
+		require.NoError(t, err)
+
+		m2, err := score.ReadModel(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, "test", m2.Language)
+		assert.Equal(t, m.Score([]byte("the hat")), m2.Score([]byte("the hat")))
+		assert.True(t, m2.Score([]byte("the hat")) > m2.Score([]byte("xzq jvk")))
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "en.model")
+		require.NoError(t, score.English().Save(path))
+
+		m, err := score.LoadModel(path)
+		require.NoError(t, err)
+		assert.Equal(t, score.English().Score([]byte("hello world")), m.Score([]byte("hello world")))
+	})
+
+	t.Run("invalid model", func(t *testing.T) {
+		_, err := score.ReadModel(strings.NewReader("language en\norder 3\n"))
+		assert.Equal(t, score.ErrInvalidModel, err)
+	})
+
+	t.Run("detect language", func(t *testing.T) {
+		english := []byte("The quick brown fox jumps over the lazy dog while the farmer sleeps.")
+		french := []byte("Le vieux renard dort paisiblement pendant que les poules chantent.")
+
+		m, _ := score.DetectLanguage(english, score.Languages()...)
+		assert.Equal(t, "en", m.Language)
+
+		m, _ = score.DetectLanguage(french, score.Languages()...)
+		assert.Equal(t, "fr", m.Language)
+	})
+}
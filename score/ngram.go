@@ -0,0 +1,117 @@
+package score
+
+import (
+	"math"
+)
+
+var (
+	// englishBigrams are the most frequent bigrams in the english language,
+	// in percent.
+	// See http://norvig.com/mayzner.html.
+	englishBigrams = map[string]float64{
+		"th": 3.56, "he": 3.07, "in": 2.43, "er": 2.05, "an": 1.99,
+		"re": 1.85, "on": 1.76, "at": 1.49, "en": 1.45, "nd": 1.35,
+		"ti": 1.34, "es": 1.34, "or": 1.28, "te": 1.20, "of": 1.17,
+		"ed": 1.17, "is": 1.13, "it": 1.12, "al": 1.09, "ar": 1.07,
+		"st": 1.05, "to": 1.04, "nt": 1.04, "ng": 0.95, "se": 0.93,
+		"ha": 0.93, "as": 0.87, "ou": 0.87, "io": 0.83, "le": 0.83,
+		"ve": 0.83, "co": 0.79, "me": 0.79, "de": 0.76, "hi": 0.76,
+		"ri": 0.73, "ro": 0.73, "ic": 0.70, "ne": 0.69, "ea": 0.69,
+		"ra": 0.69, "ce": 0.65, "li": 0.62, "ch": 0.60, "ll": 0.58,
+		"be": 0.58, "ma": 0.57, "si": 0.55, "om": 0.55, "ur": 0.54,
+	}
+
+	// englishTrigrams are the most frequent trigrams in the english language,
+	// in percent.
+	// See http://practicalcryptography.com/cryptanalysis/letter-frequencies-various-languages/english-letter-frequencies/.
+	englishTrigrams = map[string]float64{
+		"the": 1.81, "and": 0.73, "ing": 0.72, "ent": 0.42, "ion": 0.42,
+		"her": 0.36, "for": 0.34, "tha": 0.33, "nth": 0.33, "int": 0.32,
+		"ere": 0.31, "tio": 0.31, "ter": 0.30, "est": 0.28, "ers": 0.28,
+		"ati": 0.26, "hat": 0.26, "ate": 0.25, "all": 0.25, "eth": 0.24,
+		"hes": 0.24, "ver": 0.24, "his": 0.24, "oft": 0.22, "ith": 0.21,
+		"fth": 0.21, "sth": 0.21, "oth": 0.21, "res": 0.21, "ont": 0.20,
+	}
+)
+
+// NGram scores candidates with the average log-likelihood of their letter
+// n-grams.
+// Candidates are lower-cased and stripped of everything but letters before
+// being split into n-grams, so n-grams can span several words.
+// Non-printable characters are scored as the least likely n-gram.
+type NGram struct {
+	n        int
+	logProbs map[string]float64
+	floor    float64
+}
+
+// NewNGram creates an n-gram scorer from the frequencies (in percent) of the
+// most common n-grams.
+// All keys must have the same length n.
+// The frequency that isn't covered by the table is evenly shared between the
+// n-grams that aren't listed.
+func NewNGram(frequencies map[string]float64) *NGram {
+	n := 0
+	total := 0.0
+	logProbs := make(map[string]float64, len(frequencies))
+	for ngram, f := range frequencies {
+		n = len(ngram)
+		total += f
+		logProbs[ngram] = math.Log(f / 100)
+	}
+
+	unlisted := math.Pow(26, float64(n)) - float64(len(frequencies))
+	remaining := (100 - total) / 100
+	if remaining <= 0 || unlisted <= 0 {
+		remaining = 0.0001
+		unlisted = 1
+	}
+
+	return &NGram{
+		n:        n,
+		logProbs: logProbs,
+		floor:    math.Log(remaining / unlisted),
+	}
+}
+
+// NewEnglishBigram creates a bigram scorer for english text.
+func NewEnglishBigram() *NGram {
+	return NewNGram(englishBigrams)
+}
+
+// NewEnglishTrigram creates a trigram scorer for english text.
+func NewEnglishTrigram() *NGram {
+	return NewNGram(englishTrigrams)
+}
+
+// Score a candidate.
+func (g *NGram) Score(candidate []byte) float64 {
+	letters := make([]byte, 0, len(candidate))
+	invalid := 0
+	for _, b := range candidate {
+		b = toLower(b)
+		if 'a' <= b && b <= 'z' {
+			letters = append(letters, b)
+		} else if !isPrintable(b) {
+			invalid++
+		}
+	}
+
+	count := invalid
+	total := float64(invalid) * g.floor
+	for i := 0; i+g.n <= len(letters); i++ {
+		logProb, ok := g.logProbs[string(letters[i:i+g.n])]
+		if !ok {
+			logProb = g.floor
+		}
+
+		total += logProb
+		count++
+	}
+
+	if count == 0 {
+		return g.floor
+	}
+
+	return total / float64(count)
+}
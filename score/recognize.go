@@ -0,0 +1,173 @@
+package score
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"unicode/utf8"
+)
+
+// PrefixHinter is implemented by scorers that recognize plaintexts from the
+// bytes they start with.
+// Breakers can use those prefixes to derive key bytes directly instead of
+// searching for them.
+type PrefixHinter interface {
+	Scorer
+	Prefixes() [][]byte
+}
+
+// Base64 scores candidates with the ratio of characters from the standard or
+// URL-safe base64 alphabets, padding and line breaks included.
+var Base64 Scorer = alphabetRatio(func(b byte) bool {
+	return ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') ||
+		b == '+' || b == '/' || b == '-' || b == '_' || b == '=' || b == '\n' || b == '\r'
+})
+
+// Hex scores candidates with the ratio of hexadecimal digits and line breaks.
+var Hex Scorer = alphabetRatio(func(b byte) bool {
+	return ('0' <= b && b <= '9') || ('a' <= b && b <= 'f') || ('A' <= b && b <= 'F') ||
+		b == '\n' || b == '\r'
+})
+
+func alphabetRatio(inAlphabet func(b byte) bool) Scorer {
+	return ScorerFunc(func(candidate []byte) float64 {
+		if len(candidate) == 0 {
+			return 0
+		}
+
+		valid := 0
+		for _, b := range candidate {
+			if inAlphabet(b) {
+				valid++
+			}
+		}
+
+		return float64(valid) / float64(len(candidate))
+	})
+}
+
+// UTF8 scores candidates with the ratio of bytes that are part of valid UTF-8
+// sequences and don't encode control characters (except whitespace).
+var UTF8 Scorer = ScorerFunc(func(candidate []byte) float64 {
+	if len(candidate) == 0 {
+		return 0
+	}
+
+	valid := 0
+	for i := 0; i < len(candidate); {
+		r, size := utf8.DecodeRune(candidate[i:])
+		if r != utf8.RuneError && (r >= 32 || r == '\n' || r == '\r' || r == '\t') && r != 127 {
+			valid += size
+		}
+
+		i += size
+	}
+
+	return float64(valid) / float64(len(candidate))
+})
+
+// JSON scores well-formed JSON documents with 1.
+// Other candidates get half their printable ratio, so that isolated
+// characters of a JSON document still score better than binary data.
+var JSON Scorer = ScorerFunc(func(candidate []byte) float64 {
+	if json.Valid(candidate) {
+		return 1
+	}
+
+	return PrintableRatio.Score(candidate) / 2
+})
+
+// FileSignature is a magic number found at the beginning of files of a given
+// type.
+type FileSignature struct {
+	Name  string
+	Magic []byte
+}
+
+// FileSignatures are the file types recognized by FileType and Signatures.
+var FileSignatures = []FileSignature{
+	{Name: "png", Magic: []byte("\x89PNG\r\n\x1a\n")},
+	{Name: "gif", Magic: []byte("GIF89a")},
+	{Name: "gif", Magic: []byte("GIF87a")},
+	{Name: "jpeg", Magic: []byte("\xff\xd8\xff")},
+	{Name: "pdf", Magic: []byte("%PDF-")},
+	{Name: "zip", Magic: []byte("PK\x03\x04")},
+	{Name: "gzip", Magic: []byte("\x1f\x8b\x08")},
+	{Name: "bzip2", Magic: []byte("BZh")},
+	{Name: "elf", Magic: []byte("\x7fELF")},
+}
+
+// FileType returns the name of the file type whose signature the data starts
+// with.
+func FileType(data []byte) (string, bool) {
+	for _, s := range FileSignatures {
+		if bytes.HasPrefix(data, s.Magic) {
+			return s.Name, true
+		}
+	}
+
+	return "", false
+}
+
+// Signatures scores candidates with the ratio of the longest file signature
+// they start with.
+// It implements PrefixHinter.
+var Signatures PrefixHinter = signatures{}
+
+type signatures struct{}
+
+// Score a candidate.
+func (signatures) Score(candidate []byte) float64 {
+	best := 0.0
+	for _, s := range FileSignatures {
+		matched := 0
+		for matched < len(s.Magic) && matched < len(candidate) && candidate[matched] == s.Magic[matched] {
+			matched++
+		}
+
+		best = math.Max(best, float64(matched)/float64(len(s.Magic)))
+	}
+
+	return best
+}
+
+// Prefixes returns the known file signatures.
+func (signatures) Prefixes() [][]byte {
+	prefixes := make([][]byte, len(FileSignatures))
+	for i, s := range FileSignatures {
+		prefixes[i] = s.Magic
+	}
+
+	return prefixes
+}
+
+// Max combines scorers by keeping the best score for each candidate.
+// The result implements PrefixHinter with the prefixes of all the combined
+// scorers that do.
+func Max(scorers ...Scorer) PrefixHinter {
+	return maxScorer(scorers)
+}
+
+type maxScorer []Scorer
+
+// Score a candidate.
+func (m maxScorer) Score(candidate []byte) float64 {
+	best := math.Inf(-1)
+	for _, s := range m {
+		best = math.Max(best, s.Score(candidate))
+	}
+
+	return best
+}
+
+// Prefixes returns the prefixes of the combined scorers.
+func (m maxScorer) Prefixes() [][]byte {
+	var prefixes [][]byte
+	for _, s := range m {
+		if h, ok := s.(PrefixHinter); ok {
+			prefixes = append(prefixes, h.Prefixes()...)
+		}
+	}
+
+	return prefixes
+}
@@ -0,0 +1,49 @@
+package score_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func TestRecognizers(t *testing.T) {
+	binary := []byte{0x00, 0x9f, 0xc3, 0x17, 0xfe, 0x01, 0x80, 0x7f}
+
+	t.Run("encodings", func(t *testing.T) {
+		assert.Equal(t, 1.0, score.Base64.Score([]byte("SSdtIGtpbGxpbmc=")))
+		assert.Equal(t, 1.0, score.Hex.Score([]byte("49276d206b696c6c")))
+		assert.True(t, score.Hex.Score([]byte("SSdtIGtpbGxpbmc=")) < 0.5)
+		assert.True(t, score.Base64.Score(binary) < 0.5)
+	})
+
+	t.Run("utf-8", func(t *testing.T) {
+		assert.Equal(t, 1.0, score.UTF8.Score([]byte("Ma jeunesse ne fut qu'un ténébreux orage")))
+		assert.True(t, score.UTF8.Score(binary) < 0.5)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert.Equal(t, 1.0, score.JSON.Score([]byte(`{"role": "admin", "uid": 10}`)))
+		assert.Equal(t, 0.5, score.JSON.Score([]byte(`{"role": "admin"`)))
+		assert.True(t, score.JSON.Score(binary) < 0.5)
+	})
+
+	t.Run("file signatures", func(t *testing.T) {
+		png := append([]byte("\x89PNG\r\n\x1a\n"), binary...)
+		fileType, ok := score.FileType(png)
+		assert.True(t, ok)
+		assert.Equal(t, "png", fileType)
+		assert.Equal(t, 1.0, score.Signatures.Score(png))
+		assert.Equal(t, 0.5, score.Signatures.Score([]byte("\x89PNGtext")))
+
+		_, ok = score.FileType(binary)
+		assert.False(t, ok)
+	})
+
+	t.Run("max", func(t *testing.T) {
+		s := score.Max(score.Hex, score.Signatures)
+		assert.Equal(t, 1.0, s.Score([]byte("deadbeef")))
+		assert.Equal(t, 1.0, s.Score([]byte("PK\x03\x04")))
+		assert.Len(t, s.Prefixes(), len(score.FileSignatures))
+	})
+}
@@ -0,0 +1,54 @@
+package score
+
+// Scorer scores decryption candidates.
+// The higher the score, the more likely the candidate is the right plaintext.
+type Scorer interface {
+	Score(candidate []byte) float64
+}
+
+// ScorerFunc adapts an ordinary function to the Scorer interface.
+type ScorerFunc func(candidate []byte) float64
+
+// Score calls f(candidate).
+func (f ScorerFunc) Score(candidate []byte) float64 {
+	return f(candidate)
+}
+
+// LetterFrequencyScorer scores candidates with LetterFrequency.
+// Its scores grow with the length of the candidates, so it should only be
+// used to compare candidates of the same length.
+var LetterFrequencyScorer Scorer = ScorerFunc(func(candidate []byte) float64 {
+	return float64(LetterFrequency(candidate))
+})
+
+// PrintableRatio scores candidates with the ratio of printable ASCII
+// characters they contain, between 0 and 1.
+var PrintableRatio Scorer = ScorerFunc(func(candidate []byte) float64 {
+	if len(candidate) == 0 {
+		return 0
+	}
+
+	printable := 0
+	for _, b := range candidate {
+		if isPrintable(b) {
+			printable++
+		}
+	}
+
+	return float64(printable) / float64(len(candidate))
+})
+
+// isPrintable returns true for printable ASCII characters and common
+// whitespace.
+func isPrintable(b byte) bool {
+	return (32 <= b && b <= 126) || b == '\n' || b == '\r' || b == '\t'
+}
+
+// toLower lower-cases ASCII letters.
+func toLower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+
+	return b
+}
@@ -0,0 +1,49 @@
+package score_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t-bast/cryptopals/score"
+)
+
+func TestScorers(t *testing.T) {
+	english := []byte("Now that the party is jumping, cooking MC's like a pound of bacon")
+	shifted := make([]byte, len(english))
+	for i, b := range english {
+		shifted[i] = b ^ 0x05
+	}
+
+	garbage := make([]byte, len(english))
+	for i := range garbage {
+		garbage[i] = byte(i * 37)
+	}
+
+	scorers := map[string]score.Scorer{
+		"letter frequency": score.LetterFrequencyScorer,
+		"chi-squared":      score.NewEnglishChiSquared(),
+		"bigram":           score.NewEnglishBigram(),
+		"trigram":          score.NewEnglishTrigram(),
+	}
+
+	for name, s := range scorers {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, s.Score(english) > s.Score(shifted))
+			assert.True(t, s.Score(english) > s.Score(garbage))
+		})
+	}
+
+	t.Run("printable ratio", func(t *testing.T) {
+		assert.Equal(t, 1.0, score.PrintableRatio.Score(english))
+		assert.Equal(t, 0.5, score.PrintableRatio.Score([]byte{'a', 0x00}))
+		assert.True(t, score.PrintableRatio.Score(garbage) < 1)
+	})
+
+	t.Run("length-independent scores", func(t *testing.T) {
+		chi2 := score.NewEnglishChiSquared()
+		assert.InDelta(t, chi2.Score(english), chi2.Score(append(english, english...)), 1e-9)
+
+		bigram := score.NewEnglishBigram()
+		assert.True(t, bigram.Score(english) > bigram.Score(append(english, garbage...)))
+	})
+}
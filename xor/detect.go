@@ -0,0 +1,197 @@
+package xor
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/t-bast/cryptopals/score"
+)
+
+// LineFormat is the encoding of the lines of a ciphertext corpus.
+type LineFormat int
+
+// Line formats.
+const (
+	HexLines    LineFormat = 0
+	Base64Lines LineFormat = 1
+	RawLines    LineFormat = 2
+)
+
+// maxLineSize is the longest line DetectSingleByte can read.
+const maxLineSize = 1 << 20
+
+// Detection is a line of a corpus that may have been encrypted with a
+// single-byte XOR.
+type Detection struct {
+	// Line is the 1-based line number in the corpus.
+	Line      int
+	Key       byte
+	Plaintext []byte
+	Score     float64
+}
+
+// DetectOptions configure DetectSingleByteWithOptions.
+// The zero value uses sensible defaults.
+type DetectOptions struct {
+	// Scorer ranks the best decryption of each line (defaults to the
+	// built-in english model).
+	// Lines can have different lengths, so its scores shouldn't depend on the
+	// candidate length.
+	Scorer score.Scorer
+	// Workers is the number of lines decrypted concurrently (defaults to the
+	// number of CPUs).
+	Workers int
+	// Results is the number of detections returned (defaults to 10).
+	Results int
+}
+
+// DetectSingleByte finds the lines of a corpus that are the most likely to
+// have been encrypted with a single-byte XOR.
+// See DetectSingleByteWithOptions.
+func DetectSingleByte(r io.Reader, format LineFormat) ([]Detection, error) {
+	return DetectSingleByteWithOptions(r, format, DetectOptions{})
+}
+
+// DetectSingleByteWithOptions finds the lines of a corpus that are the most
+// likely to have been encrypted with a single-byte XOR.
+// The corpus is streamed and lines are broken concurrently: only the best
+// detections are kept in memory.
+// Empty lines and lines that can't be decoded are skipped.
+// It returns the best detections, best first.
+func DetectSingleByteWithOptions(r io.Reader, format LineFormat, opts DetectOptions) ([]Detection, error) {
+	if opts.Scorer == nil {
+		opts.Scorer = score.English()
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	if opts.Results <= 0 {
+		opts.Results = 10
+	}
+
+	type job struct {
+		line int
+		data []byte
+	}
+
+	jobs := make(chan job, 4*opts.Workers)
+	results := make(chan Detection, 4*opts.Workers)
+
+	var workers sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				ciphertext, ok := decodeLine(j.data, format)
+				if !ok {
+					continue
+				}
+
+				key, plaintext, s := BreakSingleByte(ciphertext, opts.Scorer)
+				results <- Detection{Line: j.line, Key: key, Plaintext: plaintext, Score: s}
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 4096), maxLineSize)
+		for line := 1; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+
+			data := make([]byte, len(scanner.Bytes()))
+			copy(data, scanner.Bytes())
+			jobs <- job{line: line, data: data}
+		}
+
+		readErr = scanner.Err()
+		close(jobs)
+		workers.Wait()
+		close(results)
+	}()
+
+	best := &detectionHeap{}
+	for d := range results {
+		if best.Len() < opts.Results {
+			heap.Push(best, d)
+		} else if best.less(best.items[0], d) {
+			best.items[0] = d
+			heap.Fix(best, 0)
+		}
+	}
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	detections := best.items
+	sort.Slice(detections, func(i, j int) bool {
+		return best.less(detections[j], detections[i])
+	})
+
+	return detections, nil
+}
+
+// decodeLine decodes a line of the corpus.
+func decodeLine(line []byte, format LineFormat) ([]byte, bool) {
+	switch format {
+	case HexLines:
+		decoded := make([]byte, hex.DecodedLen(len(line)))
+		n, err := hex.Decode(decoded, line)
+		return decoded[:n], err == nil
+	case Base64Lines:
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+		n, err := base64.StdEncoding.Decode(decoded, line)
+		return decoded[:n], err == nil
+	default:
+		return line, true
+	}
+}
+
+// detectionHeap is a min-heap of detections: the worst one is on top.
+type detectionHeap struct {
+	items []Detection
+}
+
+// less orders detections by score, then prefers earlier lines.
+func (h *detectionHeap) less(a, b Detection) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+
+	return a.Line > b.Line
+}
+
+func (h *detectionHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *detectionHeap) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+func (h *detectionHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *detectionHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(Detection))
+}
+
+func (h *detectionHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,53 @@
+package xor_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestDetectSingleByte(t *testing.T) {
+	encrypt := func(message string, key byte) string {
+		encrypted := []byte(message)
+		for i := range encrypted {
+			encrypted[i] ^= key
+		}
+
+		return base64.StdEncoding.EncodeToString(encrypted)
+	}
+
+	lines := []string{
+		base64.StdEncoding.EncodeToString([]byte{0x13, 0x99, 0x42, 0x07, 0xfe, 0x88, 0x61, 0x3c}),
+		"not base64!",
+		"",
+		encrypt("Quand le ciel bas et lourd pese comme un couvercle", 0x42),
+		base64.StdEncoding.EncodeToString([]byte{0xa7, 0x1f, 0x0c, 0xd3, 0x55, 0x00, 0x9e}),
+		encrypt("Now that the party is jumping", 0x35),
+	}
+
+	detections, err := xor.DetectSingleByteWithOptions(
+		strings.NewReader(strings.Join(lines, "\n")),
+		xor.Base64Lines,
+		xor.DetectOptions{Workers: 3, Results: 3},
+	)
+	require.NoError(t, err)
+	require.Len(t, detections, 3)
+
+	found := map[int]xor.Detection{}
+	for _, d := range detections[:2] {
+		found[d.Line] = d
+	}
+
+	require.Contains(t, found, 4)
+	assert.Equal(t, byte(0x42), found[4].Key)
+	assert.Equal(t, "Quand le ciel bas et lourd pese comme un couvercle", string(found[4].Plaintext))
+
+	require.Contains(t, found, 6)
+	assert.Equal(t, byte(0x35), found[6].Key)
+
+	assert.True(t, detections[1].Score >= detections[2].Score)
+}
@@ -2,26 +2,54 @@ package xor
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 )
 
+// ErrLengthMismatch is returned when XOR-ing inputs of different lengths.
+var ErrLengthMismatch = errors.New("inputs have different lengths")
+
 // Hex XORs two hex strings and returns the hex encoding of the result.
+// It panics on invalid input: use CheckedHex to get an error instead.
 func Hex(s1, s2 string) string {
-	b1, err := hex.DecodeString(s1)
+	x, err := CheckedHex(s1, s2)
 	if err != nil {
 		panic(err)
 	}
 
+	return x
+}
+
+// CheckedHex XORs two hex strings and returns the hex encoding of the result.
+// It returns an error if a string isn't valid hex or if their decoded lengths
+// differ.
+func CheckedHex(s1, s2 string) (string, error) {
+	b1, err := hex.DecodeString(s1)
+	if err != nil {
+		return "", fmt.Errorf("invalid first operand: %w", err)
+	}
+
 	b2, err := hex.DecodeString(s2)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("invalid second operand: %w", err)
+	}
+
+	if err := XORInto(b1, b1, b2); err != nil {
+		return "", err
 	}
 
-	b := Bytes(b1, b2)
-	return hex.EncodeToString(b)
+	return hex.EncodeToString(b1), nil
 }
 
 // Bytes XORs two byte arrays that must have the same size.
+// Extra bytes in b2 are ignored, but it panics if b2 is shorter than b1: use
+// CheckedBytes to get an error instead.
 func Bytes(b1, b2 []byte) []byte {
+	if len(b2) < len(b1) {
+		panic(ErrLengthMismatch)
+	}
+
 	b := make([]byte, len(b1))
 	for i := 0; i < len(b1); i++ {
 		b[i] = b1[i] ^ b2[i]
@@ -29,3 +57,33 @@ func Bytes(b1, b2 []byte) []byte {
 
 	return b
 }
+
+// CheckedBytes XORs two byte arrays and returns the result in a new slice.
+// It returns an error if the arrays don't have the same size.
+func CheckedBytes(b1, b2 []byte) ([]byte, error) {
+	b := make([]byte, len(b1))
+	if err := XORInto(b, b1, b2); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// XORInto XORs a and b and writes the result to dst without allocating.
+// The inputs must have the same size and dst must be at least as long; dst
+// may be the same slice as a or b to XOR in place.
+func XORInto(dst, a, b []byte) error {
+	if len(a) != len(b) {
+		return ErrLengthMismatch
+	}
+
+	if len(dst) < len(a) {
+		return io.ErrShortBuffer
+	}
+
+	for i := 0; i < len(a); i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package xor_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestCheckedHex(t *testing.T) {
+	t.Run("valid input", func(t *testing.T) {
+		x, err := xor.CheckedHex("1c0111001f010100061a024b53535009181c", "686974207468652062756c6c277320657965")
+		require.NoError(t, err)
+		assert.Equal(t, "746865206b696420646f6e277420706c6179", x)
+	})
+
+	t.Run("invalid hex", func(t *testing.T) {
+		_, err := xor.CheckedHex("1c01zz", "686974")
+		assert.Error(t, err)
+	})
+
+	t.Run("length mismatch", func(t *testing.T) {
+		_, err := xor.CheckedHex("1c0111", "6869")
+		assert.Equal(t, xor.ErrLengthMismatch, err)
+	})
+}
+
+func TestCheckedBytes(t *testing.T) {
+	x, err := xor.CheckedBytes([]byte{0x0F, 0xF0}, []byte{0xFF, 0xFF})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xF0, 0x0F}, x)
+
+	_, err = xor.CheckedBytes([]byte{0x0F, 0xF0}, []byte{0xFF})
+	assert.Equal(t, xor.ErrLengthMismatch, err)
+
+	assert.Panics(t, func() { xor.Bytes([]byte{0x0F, 0xF0}, []byte{0xFF}) })
+}
+
+func TestXORInto(t *testing.T) {
+	t.Run("in place", func(t *testing.T) {
+		a := []byte("YELLOW")
+		require.NoError(t, xor.XORInto(a, a, a))
+		assert.Equal(t, make([]byte, 6), a)
+	})
+
+	t.Run("short destination", func(t *testing.T) {
+		err := xor.XORInto(make([]byte, 2), []byte("abc"), []byte("def"))
+		assert.Equal(t, io.ErrShortBuffer, err)
+	})
+}
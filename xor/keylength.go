@@ -0,0 +1,159 @@
+package xor
+
+import (
+	"sort"
+
+	"github.com/t-bast/cryptopals/distance"
+)
+
+// KeyLength is a possible key size of a repeating-key XOR ciphertext, with
+// its score: the higher the score, the more likely the key size.
+type KeyLength struct {
+	Size  int
+	Score float64
+}
+
+// KeyLengthEstimator scores the key sizes between minSize and maxSize that
+// leave at least two blocks of ciphertext.
+// It returns them best first.
+type KeyLengthEstimator func(ciphertext []byte, minSize, maxSize int) []KeyLength
+
+// KeyLengthCandidates returns the possible key sizes of a ciphertext
+// encrypted with repeating-key XOR, most likely first.
+// It uses the key size bounds and estimators of the options.
+// When several estimators are used, each of them ranks the key sizes and they
+// are combined with a Borda count: scores are then the fraction of the
+// maximum number of points.
+func KeyLengthCandidates(ciphertext []byte, opts Options) []KeyLength {
+	opts = opts.withDefaults()
+	if len(opts.Estimators) == 1 {
+		return opts.Estimators[0](ciphertext, opts.MinKeySize, opts.MaxKeySize)
+	}
+
+	points := make(map[int]int)
+	maxPoints := 0
+	for _, estimate := range opts.Estimators {
+		ranking := estimate(ciphertext, opts.MinKeySize, opts.MaxKeySize)
+		for rank, l := range ranking {
+			points[l.Size] += len(ranking) - rank
+		}
+
+		maxPoints += len(ranking)
+	}
+
+	candidates := make([]KeyLength, 0, len(points))
+	for size, p := range points {
+		candidates = append(candidates, KeyLength{
+			Size:  size,
+			Score: float64(p) / float64(maxPoints),
+		})
+	}
+
+	sortKeyLengths(candidates)
+	return candidates
+}
+
+// HammingEstimator scores key sizes with the normalized hamming distance
+// between consecutive blocks, averaged over all blocks: bytes encrypted with
+// the same key byte are closer to each other.
+// Scores are negated distances.
+func HammingEstimator(ciphertext []byte, minSize, maxSize int) []KeyLength {
+	var candidates []KeyLength
+	for size := minSize; size <= maxSize && 2*size <= len(ciphertext); size++ {
+		blockCount := len(ciphertext) / size
+		total := 0.0
+		pairs := 0
+		for i := 0; i+1 < blockCount; i++ {
+			d, _ := distance.NormalizedHamming(
+				ciphertext[i*size:(i+1)*size],
+				ciphertext[(i+1)*size:(i+2)*size],
+				distance.Strict)
+			total += d
+			pairs++
+		}
+
+		candidates = append(candidates, KeyLength{Size: size, Score: -total / float64(pairs)})
+	}
+
+	sortKeyLengths(candidates)
+	return candidates
+}
+
+// CoincidenceEstimator scores key sizes with the index of coincidence of the
+// columns of bytes encrypted with the same key byte, averaged over all
+// columns: columns of the right size keep the uneven distribution of the
+// plaintext.
+func CoincidenceEstimator(ciphertext []byte, minSize, maxSize int) []KeyLength {
+	var candidates []KeyLength
+	for size := minSize; size <= maxSize && 2*size <= len(ciphertext); size++ {
+		total := 0.0
+		column := make([]byte, 0, len(ciphertext)/size+1)
+		for i := 0; i < size; i++ {
+			column = column[:0]
+			for j := i; j < len(ciphertext); j += size {
+				column = append(column, ciphertext[j])
+			}
+
+			total += distance.IndexOfCoincidence(column)
+		}
+
+		candidates = append(candidates, KeyLength{Size: size, Score: total / float64(size)})
+	}
+
+	sortKeyLengths(candidates)
+	return candidates
+}
+
+// kasiskiLength is the length of the repeated substrings used by the Kasiski
+// examination.
+const kasiskiLength = 3
+
+// KasiskiEstimator scores key sizes with the Kasiski examination: when the
+// same plaintext is encrypted with the same part of the key, the ciphertext
+// repeats, so the spacing between repeated substrings is a multiple of the
+// key size.
+// Each key size gets a vote for every spacing it divides, and scores compare
+// the votes to the number expected by chance.
+func KasiskiEstimator(ciphertext []byte, minSize, maxSize int) []KeyLength {
+	var spacings []int
+	lastSeen := make(map[string]int)
+	for i := 0; i+kasiskiLength <= len(ciphertext); i++ {
+		s := string(ciphertext[i : i+kasiskiLength])
+		if last, ok := lastSeen[s]; ok {
+			spacings = append(spacings, i-last)
+		}
+
+		lastSeen[s] = i
+	}
+
+	var candidates []KeyLength
+	for size := minSize; size <= maxSize && 2*size <= len(ciphertext); size++ {
+		votes := 0
+		for _, spacing := range spacings {
+			if spacing%size == 0 {
+				votes++
+			}
+		}
+
+		s := 0.0
+		if len(spacings) > 0 {
+			s = float64(votes*size) / float64(len(spacings))
+		}
+
+		candidates = append(candidates, KeyLength{Size: size, Score: s})
+	}
+
+	sortKeyLengths(candidates)
+	return candidates
+}
+
+// sortKeyLengths sorts key lengths best first, preferring smaller sizes.
+func sortKeyLengths(candidates []KeyLength) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+
+		return candidates[i].Size < candidates[j].Size
+	})
+}
@@ -0,0 +1,72 @@
+package xor_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestKeyLengthCandidates(t *testing.T) {
+	encoded, err := ioutil.ReadFile(filepath.Join("..", "challenge", "testdata", "1_6.txt"))
+	require.NoError(t, err)
+
+	ciphertext, err := base64.StdEncoding.DecodeString(string(encoded))
+	require.NoError(t, err)
+
+	estimators := map[string][]xor.KeyLengthEstimator{
+		"hamming":     {xor.HammingEstimator},
+		"coincidence": {xor.CoincidenceEstimator},
+		"kasiski":     {xor.KasiskiEstimator},
+		"voting":      {xor.HammingEstimator, xor.CoincidenceEstimator, xor.KasiskiEstimator},
+	}
+
+	for name, e := range estimators {
+		t.Run(name, func(t *testing.T) {
+			candidates := xor.KeyLengthCandidates(ciphertext, xor.Options{MaxKeySize: 40, Estimators: e})
+			require.Len(t, candidates, 39)
+			assert.Equal(t, 29, candidates[0].Size)
+		})
+	}
+
+	t.Run("hamming over all blocks", func(t *testing.T) {
+		plaintext, err := hex.DecodeString(xor.EncryptWithRepeat("Terminator X: Bring the noise", string(ciphertext)))
+		require.NoError(t, err)
+
+		// The first 16 blocks use a key of size 8, but most of the message
+		// uses a key of size 5.
+		first, err := hex.DecodeString(xor.EncryptWithRepeat("HUIT1234", string(plaintext[:240])))
+		require.NoError(t, err)
+		rest, err := hex.DecodeString(xor.EncryptWithRepeat("FIVES", string(plaintext[240:])))
+		require.NoError(t, err)
+
+		candidates := xor.HammingEstimator(append(first, rest...), 2, 10)
+		assert.Equal(t, 5, candidates[0].Size)
+	})
+
+	t.Run("short ciphertext", func(t *testing.T) {
+		candidates := xor.KeyLengthCandidates(ciphertext[:10], xor.Options{})
+		assert.Len(t, candidates, 4)
+		assert.Empty(t, xor.KeyLengthCandidates(ciphertext[:3], xor.Options{}))
+	})
+}
+
+func BenchmarkHammingEstimator(b *testing.B) {
+	for _, size := range []int{10 << 10, 200 << 10} {
+		ciphertext := make([]byte, size)
+		rand.Read(ciphertext)
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				xor.HammingEstimator(ciphertext, 2, 60)
+			}
+		})
+	}
+}
@@ -3,9 +3,10 @@ package xor
 import (
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"io"
 	"sort"
 
-	"github.com/t-bast/cryptopals/distance"
 	"github.com/t-bast/cryptopals/score"
 )
 
@@ -21,6 +22,19 @@ func EncryptWithRepeat(key, message string) string {
 	return hex.EncodeToString(encryptedBytes)
 }
 
+// EncryptStreamWithRepeat encrypts everything read from r with a
+// repeating-key XOR and writes the raw encrypted bytes to w.
+// Decryption is the same operation.
+// It returns the number of bytes written.
+func EncryptStreamWithRepeat(w io.Writer, r io.Reader, key []byte) (int64, error) {
+	keystream, err := NewRepeatingKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return io.Copy(NewWriter(w, keystream), r)
+}
+
 // DecryptWithRepeat decrypts the given ciphertext that was encrypted with
 // repeating-key XOR.
 // The ciphertext is expected to be in base64 encoding.
@@ -30,84 +44,253 @@ func DecryptWithRepeat(ciphertext string) string {
 		panic(err)
 	}
 
-	var bestScore float32
-	var best []byte
+	_, candidates, err := BreakRepeatingXOR(b, Options{})
+	if err != nil {
+		panic(err)
+	}
+
+	return string(candidates[0].Plaintext)
+}
+
+// ErrCiphertextTooShort is returned when a ciphertext doesn't contain enough
+// data to be analyzed.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// Options to configure BreakRepeatingXOR.
+// The zero value uses sensible defaults.
+type Options struct {
+	// MinKeySize is the smallest key size tried (defaults to 2).
+	MinKeySize int
+	// MaxKeySize is the largest key size tried (defaults to 60).
+	// It is capped so that at least two blocks of ciphertext are available.
+	MaxKeySize int
+	// Estimators are used to rank key sizes (defaults to HammingEstimator).
+	// When several estimators are given, they vote for the key sizes.
+	Estimators []KeyLengthEstimator
+	// Candidates is the number of most likely key sizes that are fully
+	// decrypted and returned (defaults to 3).
+	// Key sizes that give the same key as a previous one are skipped.
+	Candidates int
+	// Scorer is used to recover each key byte and to rank candidates
+	// (defaults to score.LetterFrequencyScorer).
+	// Key bytes are scored on every n-th byte of the ciphertext, so the scorer
+	// should work on isolated characters rather than on words.
+	Scorer score.Scorer
+	// Models are language models to try instead of Scorer, for example
+	// score.Languages().
+	// Each key is recovered with the unigram view of every model and
+	// candidates are ranked with the full models, so the best language is
+	// picked for the ciphertext.
+	Models []*score.Model
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinKeySize <= 0 {
+		o.MinKeySize = 2
+	}
+
+	if o.MaxKeySize <= 0 {
+		o.MaxKeySize = 60
+	}
+
+	if len(o.Estimators) == 0 {
+		o.Estimators = []KeyLengthEstimator{HammingEstimator}
+	}
+
+	if o.Candidates <= 0 {
+		o.Candidates = 3
+	}
+
+	if o.Scorer == nil {
+		o.Scorer = score.LetterFrequencyScorer
+	}
+
+	return o
+}
+
+// Candidate is a possible decryption of a repeating-key XOR ciphertext.
+type Candidate struct {
+	Key       []byte
+	Plaintext []byte
+	Score     float64
+	// Language of the model that produced this candidate, if any.
+	Language string
+}
+
+// BreakRepeatingXOR recovers the key of a ciphertext that was encrypted with
+// repeating-key XOR.
+// It returns the most likely key and the ranked candidates it was chosen from,
+// best first.
+func BreakRepeatingXOR(ciphertext []byte, opts Options) ([]byte, []Candidate, error) {
+	opts = opts.withDefaults()
+
+	keyLengths := KeyLengthCandidates(ciphertext, opts)
+	if len(keyLengths) == 0 {
+		return nil, nil, ErrCiphertextTooShort
+	}
+
+	keySizes := make([]int, len(keyLengths))
+	for i, l := range keyLengths {
+		keySizes[i] = l.Size
+	}
+
+	var candidates []Candidate
+	if len(opts.Models) == 0 {
+		candidates = breakWithKeySizes(ciphertext, keySizes, opts.Candidates, opts.Scorer, opts.Scorer)
+	} else {
+		for _, m := range opts.Models {
+			languageCandidates := breakWithKeySizes(ciphertext, keySizes, opts.Candidates, m.WithOrder(1), m)
+			for i := range languageCandidates {
+				languageCandidates[i].Language = m.Language
+			}
+
+			candidates = append(candidates, languageCandidates...)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) > opts.Candidates {
+		candidates = candidates[:opts.Candidates]
+	}
+
+	return candidates[0].Key, candidates, nil
+}
 
-	keySizes := keySizeCandidates(b)
+// breakWithKeySizes recovers a key for the given key sizes with the column
+// scorer, and scores the resulting plaintexts with the ranker.
+// Key sizes are tried in order until count of them produced new keys.
+// If the ranker recognizes plaintexts by their prefix, each prefix is also
+// tried to derive the first key bytes.
+func breakWithKeySizes(ciphertext []byte, keySizes []int, count int, column, ranker score.Scorer) []Candidate {
+	prefixes := [][]byte{nil}
+	if h, ok := ranker.(score.PrefixHinter); ok {
+		prefixes = append(prefixes, h.Prefixes()...)
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]struct{})
+	recovered := make(map[string]struct{})
 	for _, keySize := range keySizes {
-		decrypted := decryptWithRepeat(keySize, b)
-		score := score.LetterFrequency(decrypted)
-		if bestScore < score {
-			bestScore = score
-			best = decrypted
+		if count == 0 {
+			break
+		}
+
+		found := false
+		for _, prefix := range prefixes {
+			key, decrypted := decryptWithRepeat(keySize, ciphertext, column, prefix)
+
+			// Multiples of the real key size produce the same key repeated:
+			// we only keep one candidate per key. Longer keys always fit the
+			// text a bit better, so unless the shorter key was already
+			// recovered with its own size, the repeated key is only kept if
+			// it decrypts better.
+			if short := shortestPeriod(key); len(short) < len(key) {
+				shortDecrypted := repeatXOR(ciphertext, short)
+				_, ok := recovered[string(short)]
+				if ok || ranker.Score(shortDecrypted) >= ranker.Score(decrypted) {
+					key, decrypted = short, shortDecrypted
+				}
+			} else {
+				recovered[string(key)] = struct{}{}
+			}
+
+			if _, ok := seen[string(key)]; ok {
+				continue
+			}
+
+			seen[string(key)] = struct{}{}
+			found = true
+			candidates = append(candidates, Candidate{
+				Key:       key,
+				Plaintext: decrypted,
+				Score:     ranker.Score(decrypted),
+			})
+		}
+
+		if found {
+			count--
 		}
 	}
 
-	return string(best)
+	return candidates
 }
 
 // decryptWithRepeat finds the most likely key with the given size and decrypts
 // the ciphertext with that key.
-func decryptWithRepeat(keySize int, ciphertext []byte) []byte {
+// Key bytes covered by the known plaintext prefix are derived from it.
+func decryptWithRepeat(keySize int, ciphertext []byte, s score.Scorer, prefix []byte) ([]byte, []byte) {
 	// Get the key from block transposition.
 	key := make([]byte, keySize)
 	for i := 0; i < keySize; i++ {
-		var block []byte
-		for j := 0; j < len(ciphertext)/keySize; j++ {
-			block = append(block, ciphertext[i+j*keySize])
+		if i < len(prefix) && i < len(ciphertext) {
+			key[i] = ciphertext[i] ^ prefix[i]
+			continue
 		}
 
-		_, key[i] = decryptSingle(block)
-	}
+		var block []byte
+		for j := i; j < len(ciphertext); j += keySize {
+			block = append(block, ciphertext[j])
+		}
 
-	// Decrypt.
-	decrypted := make([]byte, len(ciphertext))
-	for i, b := range ciphertext {
-		decrypted[i] = b ^ key[i%keySize]
+		key[i], _, _ = BreakSingleByte(block, s)
 	}
 
-	return decrypted
-}
-
-type keySize struct {
-	Value int
-	Score float32
+	return key, repeatXOR(ciphertext, key)
 }
 
-type byScore []keySize
+// repeatXOR XORs the input with the repeated key.
+func repeatXOR(b, key []byte) []byte {
+	x := make([]byte, len(b))
+	for i := range b {
+		x[i] = b[i] ^ key[i%len(key)]
+	}
 
-func (s byScore) Len() int {
-	return len(s)
+	return x
 }
 
-func (s byScore) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
-}
+// shortestPeriod returns the shortest key that repeats to form the whole key.
+// Bytes of long keys are recovered from few ciphertext bytes, so a key byte
+// may be wrong in some repetitions: each byte of the shorter key only needs to
+// be the same in a strict majority of its repetitions.
+// Real keys can look like that too, so the shorter key must be scored
+// against the whole one.
+func shortestPeriod(key []byte) []byte {
+	for period := 1; period < len(key); period++ {
+		if len(key)%period != 0 {
+			continue
+		}
+
+		if short, ok := majorityPeriod(key, period); ok {
+			return short
+		}
+	}
 
-func (s byScore) Less(i, j int) bool {
-	return s[i].Score <= s[j].Score
+	return key
 }
 
-// keySizeCandidates returns the most likely key sizes of an encrypted message
-// that uses repeating-key XOR.
-func keySizeCandidates(b []byte) []int {
-	var candidates byScore
-	for i := 2; i <= 60; i++ {
-		d1 := distance.Hamming(string(b[:i]), string(b[i:2*i]))
-		d2 := distance.Hamming(string(b[:i]), string(b[2*i:3*i]))
-		d3 := distance.Hamming(string(b[:i]), string(b[3*i:4*i]))
-		d4 := distance.Hamming(string(b[i:2*i]), string(b[2*i:3*i]))
-		d5 := distance.Hamming(string(b[i:2*i]), string(b[3*i:4*i]))
-		d6 := distance.Hamming(string(b[2*i:3*i]), string(b[3*i:4*i]))
-		d := float32(d1+d2+d3+d4+d5+d6) / 6
+// majorityPeriod returns the key of the given period whose bytes are the most
+// common ones in their repetitions, if they all are in a strict majority.
+func majorityPeriod(key []byte, period int) ([]byte, bool) {
+	repetitions := len(key) / period
+	short := make([]byte, period)
+	for i := 0; i < period; i++ {
+		counts := make(map[byte]int)
+		best := 0
+		for j := i; j < len(key); j += period {
+			counts[key[j]]++
+			if counts[key[j]] > best {
+				best = counts[key[j]]
+				short[i] = key[j]
+			}
+		}
 
-		candidates = append(candidates, keySize{
-			Value: i,
-			Score: d / float32(i),
-		})
+		if 2*best <= repetitions {
+			return nil, false
+		}
 	}
 
-	sort.Sort(candidates)
-
-	return []int{candidates[0].Value, candidates[1].Value, candidates[2].Value}
+	return short, true
 }
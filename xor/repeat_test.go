@@ -0,0 +1,107 @@
+package xor_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/score"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestBreakRepeatingXOR(t *testing.T) {
+	message := "I'm back and I'm ringin' the bell, a rockin' on the mike while the fly girls yell, " +
+		"in ecstasy in the back of me, well that's my DJ Deshay cuttin' all them Z's."
+
+	t.Run("short ciphertext", func(t *testing.T) {
+		ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("ICE", message))
+		require.NoError(t, err)
+
+		key, candidates, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{MaxKeySize: 10, Candidates: 5})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("ICE"), key)
+		assert.Len(t, candidates, 5)
+		assert.Equal(t, message, string(candidates[0].Plaintext))
+
+		for i := 1; i < len(candidates); i++ {
+			assert.True(t, candidates[i-1].Score >= candidates[i].Score)
+		}
+	})
+
+	t.Run("chi-squared scorer", func(t *testing.T) {
+		ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("YELLOW", message))
+		require.NoError(t, err)
+
+		key, _, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{
+			MaxKeySize: 10,
+			Scorer:     score.NewEnglishChiSquared(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("YELLOW"), key)
+	})
+
+	t.Run("key with a majority period", func(t *testing.T) {
+		ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("aaab", message))
+		require.NoError(t, err)
+
+		key, candidates, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{MaxKeySize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("aaab"), key)
+		assert.Equal(t, message, string(candidates[0].Plaintext))
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, _, err := xor.BreakRepeatingXOR([]byte{0x42, 0x43, 0x44}, xor.Options{})
+		assert.Equal(t, xor.ErrCiphertextTooShort, err)
+	})
+}
+
+func TestBreakRepeatingXORLanguages(t *testing.T) {
+	message := "Rappelez-vous l'objet que nous vîmes, mon âme, ce beau matin d'été si doux : " +
+		"au détour d'un sentier une charogne infâme sur un lit semé de cailloux, " +
+		"les jambes en l'air, comme une femme lubrique, brûlante et suant les poisons."
+	ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("SPLEEN", message))
+	require.NoError(t, err)
+
+	key, candidates, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{
+		MaxKeySize: 20,
+		Candidates: 10,
+		Models:     score.Languages(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("SPLEEN"), key)
+	assert.Equal(t, "fr", candidates[0].Language)
+	assert.Equal(t, message, string(candidates[0].Plaintext))
+}
+
+func TestBreakRepeatingXORStructured(t *testing.T) {
+	t.Run("file signature", func(t *testing.T) {
+		plaintext := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR\x00\x00\x00\x10\x00\x00\x00\x10\x08\x06\x00\x00\x00")
+		ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("\x13\x37\xc0\xde\x42", string(plaintext)))
+		require.NoError(t, err)
+
+		key, candidates, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{
+			MinKeySize: 5,
+			MaxKeySize: 5,
+			Scorer:     score.Signatures,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x13\x37\xc0\xde\x42"), key)
+		assert.Equal(t, plaintext, candidates[0].Plaintext)
+	})
+
+	t.Run("hex encoding", func(t *testing.T) {
+		plaintext := "49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d"
+		ciphertext, err := hex.DecodeString(xor.EncryptWithRepeat("\x80\x81\x82", plaintext))
+		require.NoError(t, err)
+
+		key, _, err := xor.BreakRepeatingXOR(ciphertext, xor.Options{
+			MinKeySize: 3,
+			MaxKeySize: 3,
+			Scorer:     score.Hex,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x80\x81\x82"), key)
+	})
+}
@@ -2,6 +2,7 @@ package xor
 
 import (
 	"encoding/hex"
+	"math"
 
 	"github.com/t-bast/cryptopals/score"
 )
@@ -21,24 +22,31 @@ func DecryptSingle(c string) (string, byte) {
 }
 
 func decryptSingle(c []byte) (string, byte) {
+	key, decrypted, _ := BreakSingleByte(c, score.LetterFrequencyScorer)
+	return string(decrypted), key
+}
+
+// BreakSingleByte finds the single-byte key that was used to XOR the given
+// ciphertext.
+// It returns the candidate that the scorer finds the most likely, with its
+// key and score.
+func BreakSingleByte(c []byte, s score.Scorer) (byte, []byte, float64) {
 	var key byte
 	var best []byte
-	var bestScore float32
+	bestScore := math.Inf(-1)
 
-	for b := 0; b < 256; b++ {
-		b := byte(b)
-		decrypted := make([]byte, len(c))
+	decrypted := make([]byte, len(c))
+	for k := 0; k < 256; k++ {
 		for i := 0; i < len(decrypted); i++ {
-			decrypted[i] = b ^ c[i]
+			decrypted[i] = byte(k) ^ c[i]
 		}
 
-		s := score.LetterFrequency(decrypted)
-		if s > bestScore {
-			bestScore = s
-			best = decrypted
-			key = b
+		if candidateScore := s.Score(decrypted); candidateScore > bestScore {
+			bestScore = candidateScore
+			best = append(best[:0], decrypted...)
+			key = byte(k)
 		}
 	}
 
-	return string(best), key
+	return key, best, bestScore
 }
@@ -0,0 +1,43 @@
+package xor_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/score"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestBreakSingleByte(t *testing.T) {
+	ciphertext, err := hex.DecodeString("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+	require.NoError(t, err)
+
+	scorers := map[string]score.Scorer{
+		"letter frequency": score.LetterFrequencyScorer,
+		"chi-squared":      score.NewEnglishChiSquared(),
+		"bigram":           score.NewEnglishBigram(),
+	}
+
+	for name, s := range scorers {
+		t.Run(name, func(t *testing.T) {
+			key, decrypted, _ := xor.BreakSingleByte(ciphertext, s)
+			assert.Equal(t, byte('X'), key)
+			assert.Equal(t, "Cooking MC's like a pound of bacon", string(decrypted))
+		})
+	}
+}
+
+func TestBreakSingleByteFileSignature(t *testing.T) {
+	plaintext := []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xca\x48\xcd\xc9\xc9\x07\x04\x00\x00\xff\xff")
+	ciphertext := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		ciphertext[i] = b ^ 0x5a
+	}
+
+	key, decrypted, s := xor.BreakSingleByte(ciphertext, score.Signatures)
+	assert.Equal(t, byte(0x5a), key)
+	assert.Equal(t, plaintext, decrypted)
+	assert.Equal(t, 1.0, s)
+}
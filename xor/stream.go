@@ -0,0 +1,59 @@
+package xor
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// ErrEmptyKey is returned when creating a keystream from an empty key.
+var ErrEmptyKey = errors.New("empty key")
+
+// RepeatingKey is a keystream that repeats the same key indefinitely.
+// It implements cipher.Stream so it can be used with NewReader and NewWriter.
+type RepeatingKey struct {
+	key []byte
+	pos int
+}
+
+// NewRepeatingKey creates a keystream that repeats the given key.
+func NewRepeatingKey(key []byte) (*RepeatingKey, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
+	}
+
+	k := make([]byte, len(key))
+	copy(k, key)
+
+	return &RepeatingKey{key: k}, nil
+}
+
+// XORKeyStream XORs each byte of src with the next key byte and writes the
+// result to dst.
+// Successive calls continue where the previous one stopped in the key.
+func (k *RepeatingKey) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic(io.ErrShortBuffer)
+	}
+
+	for i, b := range src {
+		dst[i] = b ^ k.key[k.pos]
+		k.pos++
+		if k.pos == len(k.key) {
+			k.pos = 0
+		}
+	}
+}
+
+// NewReader returns a reader that XORs everything read from r against the
+// given keystream.
+func NewReader(r io.Reader, keystream cipher.Stream) io.Reader {
+	return &cipher.StreamReader{S: keystream, R: r}
+}
+
+// NewWriter returns a writer that XORs everything against the given keystream
+// before writing it to w.
+// Closing it closes w if w is an io.Closer.
+func NewWriter(w io.Writer, keystream cipher.Stream) io.WriteCloser {
+	return &cipher.StreamWriter{S: keystream, W: w}
+}
@@ -0,0 +1,38 @@
+package xor_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/t-bast/cryptopals/xor"
+)
+
+func TestEncryptStreamWithRepeat(t *testing.T) {
+	message := "Burning 'em, if you ain't quick and nimble\nI go crazy when I hear a cymbal"
+
+	var encrypted bytes.Buffer
+	n, err := xor.EncryptStreamWithRepeat(&encrypted, strings.NewReader(message), []byte("ICE"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(message)), n)
+	assert.Equal(t, xor.EncryptWithRepeat("ICE", message), hex.EncodeToString(encrypted.Bytes()))
+
+	_, err = xor.EncryptStreamWithRepeat(&encrypted, strings.NewReader(message), nil)
+	assert.Equal(t, xor.ErrEmptyKey, err)
+}
+
+func TestRepeatingKeyReader(t *testing.T) {
+	keystream, err := xor.NewRepeatingKey([]byte("ICE"))
+	require.NoError(t, err)
+
+	encrypted, err := hex.DecodeString(xor.EncryptWithRepeat("ICE", "I go crazy when I hear a cymbal"))
+	require.NoError(t, err)
+
+	decrypted, err := ioutil.ReadAll(xor.NewReader(bytes.NewReader(encrypted), keystream))
+	require.NoError(t, err)
+	assert.Equal(t, "I go crazy when I hear a cymbal", string(decrypted))
+}